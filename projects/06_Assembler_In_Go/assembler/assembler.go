@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -19,93 +21,251 @@ const (
 	L                        // EnumIndex = 3
 )
 
+// The Hack ROM holds 32K 16-bit words, and an A-Instruction can only
+// load a 15-bit value, so both instruction and label addresses must fit
+// in the range 0 ... MaxAddress
+const (
+	ROMSize    = 32768
+	MaxAddress = ROMSize - 1
+)
+
+// A line of assembly along with where it was read from, Line and Column
+// (of the first non-whitespace character) are 1-based
+type SourceLine struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text"`
+}
+
+func (l SourceLine) Position() string {
+	return fmt.Sprintf("%s:%v", l.File, l.Line)
+}
+
+// An error in a specific line of the source, each pass reports all of
+// these it finds, joined together with errors.Join
+type SourceError struct {
+	Source  SourceLine
+	Message string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Source.Position(), e.Message)
+}
+
+func newSourceError(line SourceLine, format string, args ...any) *SourceError {
+	return &SourceError{Source: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// An encoded instruction and the source line it was assembled from
+type ProgramInstruction struct {
+	Address int
+	Word    string
+	Source  SourceLine
+}
+
 type Assembler struct {
-	SymbolMap  map[string]int
-	nextVarAdd int
+	SymbolMap       map[string]int
+	DebugOutput     io.Writer // Progress messages, os.Stdout by default
+	symbols         map[string]*SymbolInfo
+	sourceLines     []SourceLine
+	program         []ProgramInstruction
+	memoryMap       *MemoryMap
+	nextVarAdd      int
+	numInstructions int
+	numLabels       int
+	numVariables    int
 }
 
 func NewAssembler() *Assembler {
-	assembler := &Assembler{}
+	return NewAssemblerWithMemoryMap(DefaultMemoryMap())
+}
+
+// The memory map should already be validated, see LoadMemoryMap
+func NewAssemblerWithMemoryMap(memoryMap *MemoryMap) *Assembler {
+	assembler := &Assembler{memoryMap: memoryMap, DebugOutput: os.Stdout}
 	assembler.initializeSymbolMap()
-	assembler.nextVarAdd = 16
+	assembler.nextVarAdd = memoryMap.nextFreeAddress(memoryMap.VariableStart)
 	return assembler
 }
 
 func (a *Assembler) initializeSymbolMap() {
-	symbolMap := map[string]int{
-		"SP": 0, "LCL": 1, "ARG": 2, "THIS": 3, "THAT": 4, "SCREEN": 16384, "KBD": 24576,
+	symbolMap := map[string]int{}
+	for symbol, value := range a.memoryMap.Symbols {
+		symbolMap[symbol] = value
 	}
+	a.SymbolMap = symbolMap
 
-	// R0 ... R15
-	for i := 0; i <= 15; i++ {
-		key := "R" + strconv.FormatInt(int64(i), 10)
-		symbolMap[key] = i
+	a.symbols = map[string]*SymbolInfo{}
+	for symbol, value := range symbolMap {
+		a.symbols[symbol] = &SymbolInfo{Name: symbol, Kind: PredefinedSymbol, Value: value}
 	}
-	fmt.Printf("Initial Symbol Map: \n%v \n\n", symbolMap)
-	a.SymbolMap = symbolMap
 }
 
 func (a *Assembler) Run(inputFile string) error {
-	asmLines := readASMInputFile(inputFile)
-	a.populateSymbolsMap(asmLines)
-	outputLines := a.parseAndEncodeLines(asmLines)
-	writeHackOutputFile("xxx", outputLines)
+	asmLines, err := readASMSourceLines(inputFile)
+	if err != nil {
+		return err
+	}
+
+	outputLines, err := a.Assemble(asmLines)
+	if err != nil {
+		return err
+	}
+	writeHackOutputFile(strings.TrimSuffix(inputFile, ".asm"), outputLines)
 
 	return nil
 }
 
-func (a *Assembler) populateSymbolsMap(asmLines []string) error {
+// Runs every pass over the source lines, returning the encoded program.
+// Passes carry on past errors so that all of them are reported, with
+// the symbol and cross-reference information still populated.
+func (a *Assembler) Assemble(asmLines []SourceLine) ([]string, error) {
+	fmt.Fprintf(a.DebugOutput, "Initial Symbol Map: \n%v \n\n", a.SymbolMap)
+	a.sourceLines = asmLines
+	asmLines, expandErr := a.expandDirectives(asmLines)
+	symbolsErr := a.populateSymbolsMap(asmLines)
+	outputLines, encodeErr := a.parseAndEncodeLines(asmLines)
+
+	if err := errors.Join(expandErr, symbolsErr, encodeErr); err != nil {
+		return nil, err
+	}
+	return outputLines, nil
+}
+
+func (a *Assembler) populateSymbolsMap(asmLines []SourceLine) error {
+	errs := []error{}
 	lineNumber := 0
 	for _, line := range asmLines {
-		fmt.Printf("\n%v - %v", lineNumber, line)
-		instrType := getInstructionType(line)
+		fmt.Fprintf(a.DebugOutput, "\n%v - %v", lineNumber, line.Text)
+		instrType := getInstructionType(line.Text)
 
 		if instrType != L {
 			lineNumber += 1
 			continue
 		}
-		label := strings.TrimRight(strings.TrimLeft(line, "("), ")")
-		fmt.Printf("\nAdding new symbol to map... %s=%v\n", label, lineNumber)
+		label := strings.TrimRight(strings.TrimLeft(line.Text, "("), ")")
+		if !strings.HasSuffix(line.Text, ")") || !isValidSymbol(label) {
+			errs = append(errs, newSourceError(line, "invalid label declaration: %s", line.Text))
+			continue
+		}
+		if lineNumber > MaxAddress {
+			errs = append(errs, newSourceError(line, "label (%s) would be at ROM address %v, beyond the last ROM address %v", label, lineNumber, MaxAddress))
+			continue
+		}
+		if info, exists := a.symbols[label]; exists && info.Kind != VariableSymbol {
+			if info.Definition != nil {
+				errs = append(errs, newSourceError(line, "label (%s) already defined at %v", label, info.Definition.Position()))
+			} else {
+				errs = append(errs, newSourceError(line, "label (%s) redefines a predefined symbol", label))
+			}
+			continue
+		}
+		fmt.Fprintf(a.DebugOutput, "\nAdding new symbol to map... %s=%v\n", label, lineNumber)
 		a.SymbolMap[label] = lineNumber
+		a.defineSymbol(label, LabelSymbol, lineNumber, line)
+		a.numLabels += 1
 	}
 
-	return nil
+	if lineNumber > ROMSize {
+		errs = append(errs, fmt.Errorf("program has %v instructions, overflowing the %v word ROM by %v", lineNumber, ROMSize, lineNumber-ROMSize))
+	}
+	a.numInstructions = lineNumber
+
+	return errors.Join(errs...)
 }
 
-func (a *Assembler) parseAndEncodeLines(asmLines []string) []string {
+func (a *Assembler) parseAndEncodeLines(asmLines []SourceLine) ([]string, error) {
+	errs := []error{}
 	encodedLines := []string{}
 
 	for _, asmLine := range asmLines {
 		// A, C or L(abel) instruction
-		switch instrType := getInstructionType(asmLine); instrType {
+		switch instrType := getInstructionType(asmLine.Text); instrType {
 		case A:
-			encodedLines = append(encodedLines, a.encodeAInstruction(asmLine))
+			symbol, isSymbol := getSymbol(asmLine.Text)
+			_, existed := a.SymbolMap[symbol]
+
+			encoded, err := a.encodeAInstruction(asmLine.Text)
+			if err != nil {
+				errs = append(errs, newSourceError(asmLine, "%v", err))
+				continue
+			}
+			encodedLines = append(encodedLines, encoded)
+
+			if isSymbol {
+				if !existed {
+					a.defineSymbol(symbol, VariableSymbol, a.SymbolMap[symbol], asmLine)
+				}
+				a.useSymbol(symbol, asmLine)
+			}
 		case C:
-			encodedLines = append(encodedLines, a.encodeCInstruction(asmLine))
+			if err := checkCInstruction(asmLine.Text); err != nil {
+				errs = append(errs, newSourceError(asmLine, "%v", err))
+				continue
+			}
+			encodedLines = append(encodedLines, a.encodeCInstruction(asmLine.Text))
 		case L:
-			fmt.Println("Skipping over label declaration")
+			fmt.Fprintln(a.DebugOutput, "Skipping over label declaration")
+			continue
+		}
+
+		address := len(encodedLines) - 1
+		a.program = append(a.program, ProgramInstruction{Address: address, Word: encodedLines[address], Source: asmLine})
+	}
+	return encodedLines, errors.Join(errs...)
+}
+
+// The files the program was read from, sorted
+func (a *Assembler) SourceFiles() []string {
+	files := []string{}
+	seen := map[string]bool{}
+	for _, line := range a.sourceLines {
+		if !seen[line.File] {
+			seen[line.File] = true
+			files = append(files, line.File)
 		}
 	}
-	return encodedLines
+	sort.Strings(files)
+	return files
+}
+
+// The instructions assembled by Run, in ROM order
+func (a *Assembler) Program() []ProgramInstruction {
+	return a.program
 }
 
 // e.g. @12345 -> 0011000000111001
-func (a *Assembler) encodeAInstruction(instr string) string {
+func (a *Assembler) encodeAInstruction(instr string) (string, error) {
 	label := instr[1:]
 	var address int
 
 	constant, err := strconv.ParseInt(label, 0, 16)
 
-	if err != nil {
+	if strings.HasPrefix(label, "'") {
+		// A-Instruction referenced a character literal, e.g. @'A' or @'\n'
+		code, charErr := parseCharLiteral(label)
+		if charErr != nil {
+			return "", fmt.Errorf("A-Instruction (%s): %v", instr, charErr)
+		}
+		address = code
+	} else if isConstant(label) && (err != nil || constant < 0) {
+		// Symbols can't start with a digit, so this is a constant that doesn't fit in 15 bits
+		return "", fmt.Errorf("A-Instruction (%s) constant must be in the range 0 ... %v", instr, MaxAddress)
+	} else if err != nil {
 		// A-Instruction referenced a label/var, e.g. @i or @LOOP
 		val, exists := a.SymbolMap[label]
 		address = val
 
 		if !exists {
-			fmt.Printf("\nAdding variable [%s] to Symbol Map, with address = %v\n", label, a.nextVarAdd)
+			if a.nextVarAdd > MaxAddress {
+				return "", fmt.Errorf("no free RAM address left for variable (%s)", label)
+			}
+			fmt.Fprintf(a.DebugOutput, "\nAdding variable [%s] to Symbol Map, with address = %v\n", label, a.nextVarAdd)
 			a.SymbolMap[label] = a.nextVarAdd
 			address = a.nextVarAdd
-			a.nextVarAdd += 1
+			a.nextVarAdd = a.memoryMap.nextFreeAddress(a.nextVarAdd + 1)
+			a.numVariables += 1
 		}
 
 	} else {
@@ -117,29 +277,80 @@ func (a *Assembler) encodeAInstruction(instr string) string {
 	// Return as a 16-bit string representation
 	// Where first bit is 0 to signify A-Instruction
 	numPadBits := 15 - len(bin)
-	return strings.Repeat("0", 1+numPadBits) + fmt.Sprintf(bin)
+	return strings.Repeat("0", 1+numPadBits) + fmt.Sprintf(bin), nil
+}
+
+// Returns the symbol an A-Instruction references, e.g. @LOOP -> LOOP, true
+func getSymbol(instr string) (string, bool) {
+	label := instr[1:]
+	if label == "" || isConstant(label) || strings.HasPrefix(label, "'") {
+		return "", false
+	}
+	return label, true
+}
+
+// Symbols may not begin with a digit, so anything that does (or is negative) is a constant
+func isConstant(label string) bool {
+	return label != "" && (label[0] == '-' || (label[0] >= '0' && label[0] <= '9'))
+}
+
+// Checks a C-Instruction can be encoded, i.e. dest=comp;jump with each part known
+func checkCInstruction(instr string) error {
+	if strings.Count(instr, ";") > 1 || strings.Count(instr, "=") > 1 ||
+		(strings.Contains(instr, ";") && strings.Index(instr, "=") > strings.Index(instr, ";")) {
+		return fmt.Errorf("C-Instruction (%s) must have the form dest=comp;jump", instr)
+	}
+
+	destCompInstr, jumpInstr, hasJump := strings.Cut(instr, ";")
+	if _, ok := jumpBitsMap[strings.TrimSpace(jumpInstr)]; hasJump && !ok {
+		return fmt.Errorf("C-Instruction (%s) has unknown jump (%s)", instr, strings.TrimSpace(jumpInstr))
+	}
+
+	compInstr := strings.TrimSpace(destCompInstr)
+	if destInstr, afterDest, hasDest := strings.Cut(compInstr, "="); hasDest {
+		if _, ok := destBitsMap[strings.TrimSpace(destInstr)]; !ok {
+			return fmt.Errorf("C-Instruction (%s) has unknown dest (%s)", instr, strings.TrimSpace(destInstr))
+		}
+		compInstr = afterDest
+	}
+	if _, ok := compBitsMap[compInstr]; !ok {
+		return fmt.Errorf("C-Instruction (%s) has unknown comp (%s)", instr, compInstr)
+	}
+	return nil
 }
 
 func (a *Assembler) encodeCInstruction(instr string) string {
 	return "111" + getCompBits(instr) + getDestBits(instr) + getJumpBits(instr)
 }
 
+var jumpBitsMap = map[string]string{
+	"JGT": "001",
+	"JEQ": "010",
+	"JGE": "011",
+	"JLT": "100",
+	"JNE": "101",
+	"JLE": "110",
+	"JMP": "111",
+}
+
+// Returns copies of the dest, comp and jump tables, mapping each mnemonic to its bits
+func CInstructionMnemonics() (map[string]string, map[string]string, map[string]string) {
+	copyMap := func(m map[string]string) map[string]string {
+		c := map[string]string{}
+		for k, v := range m {
+			c[k] = v
+		}
+		return c
+	}
+	return copyMap(destBitsMap), copyMap(compBitsMap), copyMap(jumpBitsMap)
+}
+
 func getJumpBits(instr string) string {
 	if !strings.Contains(instr, ";") {
 		return "000"
 	}
 	jumpInstr := strings.TrimSpace(strings.Split(instr, ";")[1])
 
-	jumpBitsMap := map[string]string{
-		"JGT": "001",
-		"JEQ": "010",
-		"JGE": "011",
-		"JLT": "100",
-		"JNE": "101",
-		"JLE": "110",
-		"JMP": "111",
-	}
-
 	jumpBits, ok := jumpBitsMap[jumpInstr]
 	if !ok {
 		log.Fatal("Error coding jumpInstr")
@@ -147,28 +358,29 @@ func getJumpBits(instr string) string {
 	return jumpBits
 }
 
+var destBitsMap = map[string]string{
+	"M":   "001",
+	"D":   "010",
+	"DM":  "011",
+	"MD":  "011",
+	"A":   "100",
+	"AM":  "101",
+	"MM":  "101",
+	"AD":  "110",
+	"DA":  "110",
+	"ADM": "111",
+	"AMD": "111",
+	"DMA": "111",
+	"DAM": "111",
+	"MAD": "111",
+	"MDA": "111",
+}
+
 func getDestBits(instr string) string {
 	if !strings.Contains(instr, "=") {
 		return "000"
 	}
 	destInstr := strings.TrimSpace(strings.Split(instr, "=")[0])
-	destBitsMap := map[string]string{
-		"M":   "001",
-		"D":   "010",
-		"DM":  "011",
-		"MD":  "011",
-		"A":   "100",
-		"AM":  "101",
-		"MM":  "101",
-		"AD":  "110",
-		"DA":  "110",
-		"ADM": "111",
-		"AMD": "111",
-		"DMA": "111",
-		"DAM": "111",
-		"MAD": "111",
-		"MDA": "111",
-	}
 
 	destBits, ok := destBitsMap[destInstr]
 	if !ok {
@@ -177,6 +389,37 @@ func getDestBits(instr string) string {
 	return destBits
 }
 
+var compBitsMap = map[string]string{
+	"0":   "0101010",
+	"1":   "0111111",
+	"-1":  "0111010",
+	"D":   "0001100",
+	"A":   "0110000",
+	"!D":  "0001101",
+	"!A":  "0110001",
+	"-D":  "0001111",
+	"-A":  "0110011",
+	"D+1": "0011111",
+	"A+1": "0110111",
+	"D-1": "0001110",
+	"A-1": "0110010",
+	"D+A": "0000010",
+	"D-A": "0010011",
+	"A-D": "0000111",
+	"D&A": "0000000",
+	"D|A": "0010101",
+	"M":   "1110000",
+	"!M":  "1110001",
+	"-M":  "1110011",
+	"M+1": "1110111",
+	"M-1": "1110010",
+	"D+M": "1000010",
+	"D-M": "1010011",
+	"M-D": "1000111",
+	"D&M": "1000000",
+	"D|M": "1010101",
+}
+
 func getCompBits(instr string) string {
 	destCompInstr := strings.TrimSpace(strings.Split(instr, ";")[0])
 	compInstr := destCompInstr
@@ -184,37 +427,6 @@ func getCompBits(instr string) string {
 		compInstr = strings.Split(destCompInstr, "=")[1]
 	}
 
-	compBitsMap := map[string]string{
-		"0":   "0101010",
-		"1":   "0111111",
-		"-1":  "0111010",
-		"D":   "0001100",
-		"A":   "0110000",
-		"!D":  "0001101",
-		"!A":  "0110001",
-		"-D":  "0001111",
-		"-A":  "0110011",
-		"D+1": "0011111",
-		"A+1": "0110111",
-		"D-1": "0001110",
-		"A-1": "0110010",
-		"D+A": "0000010",
-		"D-A": "0010011",
-		"A-D": "0000111",
-		"D&A": "0000000",
-		"D|A": "0010101",
-		"M":   "1110000",
-		"!M":  "1110001",
-		"-M":  "1110011",
-		"M+1": "1110111",
-		"M-1": "1110010",
-		"D+M": "1000010",
-		"D-M": "1010011",
-		"M-D": "1000111",
-		"D&M": "1000000",
-		"D|M": "1010101",
-	}
-
 	compBits, ok := compBitsMap[compInstr]
 	if !ok {
 		log.Fatal("Error coding compInstr")
@@ -233,31 +445,51 @@ func getInstructionType(line string) Instruction {
 }
 
 func readASMInputFile(inputFile string) []string {
+	sourceLines, err := readASMSourceLines(inputFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var lines []string
+	for _, line := range sourceLines {
+		lines = append(lines, line.Text)
+	}
+	return lines
+}
+
+func readASMSourceLines(inputFile string) ([]SourceLine, error) {
 	if !strings.HasSuffix(inputFile, ".asm") {
-		log.Fatal(errors.New("InputFile: (%s) must have .asm extension.."))
+		return nil, fmt.Errorf("input file: (%s) must have .asm extension", inputFile)
 	}
 
 	file, err := os.Open(inputFile)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer file.Close()
 
-	var lines []string
+	return ParseSourceLines(inputFile, file)
+}
 
-	scanner := bufio.NewScanner(file)
+// Splits assembly source into lines, dropping blank lines and comment lines
+func ParseSourceLines(filename string, r io.Reader) ([]SourceLine, error) {
+	var lines []SourceLine
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
 	for scanner.Scan() {
+		lineNumber++
 		trimmed := strings.TrimSpace(scanner.Text())
 		if trimmed != "" && !strings.HasPrefix(trimmed, "//") {
-			lines = append(lines, trimmed)
+			column := strings.Index(scanner.Text(), trimmed) + 1
+			lines = append(lines, SourceLine{File: filename, Line: lineNumber, Column: column, Text: trimmed})
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-
-	return lines
+	return lines, nil
 }
 
 func writeHackOutputFile(filename string, outputLines []string) {
@@ -2,6 +2,9 @@ package assembler
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -27,12 +30,27 @@ func compareSlices(str1, str2 []string) (bool, int) {
 
 func TestAssembler_Run(t *testing.T) {
 	assembler := NewAssembler()
-	assembler.Run("Rect.asm")
+	runOnCopy(t, assembler, "Rect.asm")
 
 	t.Log(assembler.SymbolMap)
 
 }
 
+// Runs the assembler on a copy of the file in a temporary directory, so
+// the .hack it writes doesn't overwrite the committed one
+func runOnCopy(t *testing.T, assembler *Assembler, filename string) error {
+	t.Helper()
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := filepath.Join(t.TempDir(), filename)
+	if err := os.WriteFile(copied, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return assembler.Run(copied)
+}
+
 func TestGetDestBits(t *testing.T) {
 	actual := getDestBits("MD=M-1")
 	expected := "011"
@@ -53,3 +71,459 @@ func TestEncodeCInstruction(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestAssembler_Summary(t *testing.T) {
+	assembler := NewAssembler()
+	if err := runOnCopy(t, assembler, "Rect.asm"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := SizeSummary{Instructions: 25, Labels: 2, Variables: 2}
+	actual := assembler.Summary()
+	if actual != expected {
+		t.Fatalf("Actual: %+v != Expected: %+v", actual, expected)
+	}
+}
+
+func TestPopulateSymbolsMap_ROMOverflow(t *testing.T) {
+	asmLines := make([]string, ROMSize+1)
+	for i := range asmLines {
+		asmLines[i] = "D=A"
+	}
+
+	err := NewAssembler().populateSymbolsMap(toSourceLines(asmLines))
+	if err == nil || !strings.Contains(err.Error(), "overflowing") {
+		t.Fatalf("Expected ROM overflow error, got: %v", err)
+	}
+}
+
+func TestPopulateSymbolsMap_LabelBeyondROM(t *testing.T) {
+	asmLines := make([]string, ROMSize)
+	for i := range asmLines {
+		asmLines[i] = "D=A"
+	}
+	asmLines = append(asmLines, "(END)")
+
+	err := NewAssembler().populateSymbolsMap(toSourceLines(asmLines))
+	if err == nil || !strings.Contains(err.Error(), "(END)") {
+		t.Fatalf("Expected label out of range error, got: %v", err)
+	}
+}
+
+func TestEncodeAInstruction_ConstantOutOfRange(t *testing.T) {
+	for _, instr := range []string{"@32768", "@-1", "@99999"} {
+		if _, err := NewAssembler().encodeAInstruction(instr); err == nil {
+			t.Errorf("Expected error encoding %s", instr)
+		}
+	}
+
+	actual, err := NewAssembler().encodeAInstruction("@32767")
+	if err != nil || actual != "0111111111111111" {
+		t.Errorf("Failed encoding @32767: %s, %v", actual, err)
+	}
+}
+
+func TestEncodeAInstruction_CharLiteral(t *testing.T) {
+	tests := map[string]string{
+		"@'A'":   "0000000001000001",
+		"@' '":   "0000000000100000",
+		"@'\\n'": "0000000010000000",
+		"@'\\''": "0000000000100111",
+	}
+	for instr, expected := range tests {
+		actual, err := NewAssembler().encodeAInstruction(instr)
+		if err != nil || actual != expected {
+			t.Errorf("Encoding %s: Actual: %s (%v) != Expected: %s", instr, actual, err, expected)
+		}
+	}
+
+	for _, instr := range []string{"@'AB'", "@''", "@'\\q'", "@'A"} {
+		if _, err := NewAssembler().encodeAInstruction(instr); err == nil {
+			t.Errorf("Expected error encoding %s", instr)
+		}
+	}
+}
+
+func TestExpandDirectives_String(t *testing.T) {
+	expected := []string{
+		"@0",
+		"@72", "D=A", "@1024", "M=D",
+		"@105", "D=A", "@1025", "M=D",
+		"@128", "D=A", "@1026", "M=D",
+		"@64", "D=A", "@16384", "M=D",
+	}
+	expandedLines, err := NewAssembler().expandDirectives(toSourceLines([]string{"@0", `.string 1024 "Hi\n"`, `.string SCREEN "@"`}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := []string{}
+	for _, line := range expandedLines {
+		actual = append(actual, line.Text)
+	}
+	assertStringSlicesEqual(t, expected, actual)
+
+	for _, line := range []string{`.string 1024 Hi`, `.string foo "Hi"`, `.string 32767 "Hi"`, `.bytes 1 "Hi"`} {
+		if _, err := NewAssembler().expandDirectives(toSourceLines([]string{line})); err == nil {
+			t.Errorf("Expected error expanding %s", line)
+		}
+	}
+}
+
+func assertStringSlicesEqual(t *testing.T, expected, actual []string) {
+	if len(expected) != len(actual) {
+		t.Fatalf("Lengths differ, Actual: %v != Expected: %v", actual, expected)
+	}
+	equal, ineqIndex := compareSlices(expected, actual)
+	if !equal {
+		t.Fatalf("Actual: %s != Expected: %s, inequal at index %v", actual[ineqIndex], expected[ineqIndex], ineqIndex)
+	}
+}
+
+func TestAssembler_CrossReference(t *testing.T) {
+	assembler := NewAssembler()
+	if err := runOnCopy(t, assembler, "Rect.asm"); err != nil {
+		t.Fatal(err)
+	}
+
+	xref := map[string]SymbolInfo{}
+	for _, info := range assembler.CrossReference() {
+		xref[info.Name] = info
+	}
+
+	if _, exists := xref["R1"]; exists {
+		t.Error("Unused predefined symbol R1 should not be in the cross-reference")
+	}
+
+	loop := xref["LOOP"]
+	if loop.Kind != LabelSymbol || loop.Value != 10 || loop.Definition.Line != 19 {
+		t.Errorf("Unexpected LOOP entry: %+v", loop)
+	}
+	if len(loop.Uses) != 1 || loop.Uses[0].Line != 31 {
+		t.Errorf("Unexpected LOOP uses: %+v", loop.Uses)
+	}
+
+	address := xref["address"]
+	if address.Kind != VariableSymbol || address.Value != 17 || address.Definition.Line != 17 || len(address.Uses) != 4 {
+		t.Errorf("Unexpected address entry: %+v", address)
+	}
+
+	screen := xref["SCREEN"]
+	if screen.Kind != PredefinedSymbol || screen.Definition != nil || len(screen.Uses) != 1 {
+		t.Errorf("Unexpected SCREEN entry: %+v", screen)
+	}
+}
+
+func toSourceLines(asmLines []string) []SourceLine {
+	lines := []SourceLine{}
+	for i, text := range asmLines {
+		lines = append(lines, SourceLine{File: "test.asm", Line: i + 1, Text: text})
+	}
+	return lines
+}
+
+func TestAssembler_ControlFlowGraph(t *testing.T) {
+	assembler := NewAssembler()
+	if err := runOnCopy(t, assembler, "Rect.asm"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := assembler.ControlFlowGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// [0-3] -> [4-9] -> (LOOP) [10-22] -> (INFINITE_LOOP) [23-24]
+	expectedBlocks := []BasicBlock{
+		{ID: 0, Labels: []string{}, Start: 0, End: 3, InstructionCount: 4},
+		{ID: 1, Labels: []string{}, Start: 4, End: 9, InstructionCount: 6},
+		{ID: 2, Labels: []string{"LOOP"}, Start: 10, End: 22, InstructionCount: 13},
+		{ID: 3, Labels: []string{"INFINITE_LOOP"}, Start: 23, End: 24, InstructionCount: 2},
+	}
+	if len(cfg.Blocks) != len(expectedBlocks) {
+		t.Fatalf("Actual: %+v != Expected: %+v", cfg.Blocks, expectedBlocks)
+	}
+	for i, block := range expectedBlocks {
+		actual := cfg.Blocks[i]
+		if actual.Start != block.Start || actual.End != block.End || actual.InstructionCount != block.InstructionCount ||
+			strings.Join(actual.Labels, ",") != strings.Join(block.Labels, ",") {
+			t.Errorf("Actual: %+v != Expected: %+v", actual, block)
+		}
+	}
+
+	expectedEdges := []CFGEdge{
+		{From: 0, To: 3, Kind: BranchEdge},
+		{From: 0, To: 1, Kind: FallthroughEdge},
+		{From: 1, To: 2, Kind: FallthroughEdge},
+		{From: 2, To: 2, Kind: BranchEdge},
+		{From: 2, To: 3, Kind: FallthroughEdge},
+		{From: 3, To: 3, Kind: JumpEdge},
+	}
+	if len(cfg.Edges) != len(expectedEdges) {
+		t.Fatalf("Actual: %+v != Expected: %+v", cfg.Edges, expectedEdges)
+	}
+	for i, edge := range expectedEdges {
+		if cfg.Edges[i] != edge {
+			t.Errorf("Actual: %+v != Expected: %+v", cfg.Edges[i], edge)
+		}
+	}
+}
+
+func TestBuildControlFlowGraph_ComputedJump(t *testing.T) {
+	// @R14, A=M, 0;JMP
+	program := []ProgramInstruction{
+		{Address: 0, Word: "0000000000001110"},
+		{Address: 1, Word: "1111110000100000"},
+		{Address: 2, Word: "1110101010000111"},
+	}
+	cfg, err := buildControlFlowGraph(program, map[int][]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Blocks) != 1 || len(cfg.Edges) != 1 || cfg.Edges[0] != (CFGEdge{From: 0, To: UnknownBlock, Kind: ComputedEdge}) {
+		t.Fatalf("Unexpected CFG: %+v", cfg)
+	}
+}
+
+func TestBuildControlFlowGraph_JumpWithComp(t *testing.T) {
+	// (LOOP) @0, D;JMP, then code that's never reached
+	program := []ProgramInstruction{
+		{Address: 0, Word: "0000000000000000"},
+		{Address: 1, Word: "1110001100000111"},
+		{Address: 2, Word: "1110101010000000"},
+	}
+	cfg, err := buildControlFlowGraph(program, map[int][]string{0: {"LOOP"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedEdges := []CFGEdge{{From: 0, To: 0, Kind: JumpEdge}}
+	if len(cfg.Blocks) != 2 || len(cfg.Edges) != len(expectedEdges) || cfg.Edges[0] != expectedEdges[0] {
+		t.Fatalf("Unexpected CFG: %+v", cfg)
+	}
+}
+
+func TestBuildControlFlowGraph_NumericTarget(t *testing.T) {
+	// @4, 0;JMP, @6, D=A, 0;JMP, then code that's never reached. Address
+	// 4 is entered from both 1 (A=4) and 3 (A=6), so its jump is computed.
+	program := []ProgramInstruction{
+		{Address: 0, Word: "0000000000000100"},
+		{Address: 1, Word: "1110101010000111"},
+		{Address: 2, Word: "0000000000000110"},
+		{Address: 3, Word: "1110110000010000"},
+		{Address: 4, Word: "1110101010000111"},
+		{Address: 5, Word: "1110101010000000"},
+		{Address: 6, Word: "1110101010000000"},
+	}
+	cfg, err := buildControlFlowGraph(program, map[int][]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedEdges := []CFGEdge{
+		{From: 0, To: 2, Kind: JumpEdge},
+		{From: 1, To: 2, Kind: FallthroughEdge},
+		{From: 2, To: UnknownBlock, Kind: ComputedEdge},
+	}
+	if len(cfg.Edges) != len(expectedEdges) {
+		t.Fatalf("Actual: %+v != Expected: %+v", cfg.Edges, expectedEdges)
+	}
+	for i, edge := range expectedEdges {
+		if cfg.Edges[i] != edge {
+			t.Errorf("Actual: %+v != Expected: %+v", cfg.Edges[i], edge)
+		}
+	}
+
+	// A jump past the end of the program is an error
+	program = []ProgramInstruction{
+		{Address: 0, Word: "0000000001100100", Source: SourceLine{File: "test.asm", Line: 1, Text: "@100"}},
+		{Address: 1, Word: "1110101010000111", Source: SourceLine{File: "test.asm", Line: 2, Text: "0;JMP"}},
+	}
+	_, err = buildControlFlowGraph(program, map[int][]string{})
+	if err == nil || !strings.Contains(err.Error(), "jump to ROM address 100, beyond the end of the program at 1") {
+		t.Errorf("Expected an error for the jump past the end, got %v", err)
+	}
+}
+
+func TestLoadMemoryMap(t *testing.T) {
+	memoryMap, err := LoadMemoryMap("ExtendedMemoryMap.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assembler := NewAssemblerWithMemoryMap(memoryMap)
+
+	// Predefined symbols from both the standard and extended maps
+	tests := [][2]string{
+		{"@SCREEN", "0100000000000000"},
+		{"@TIMER", "0110000000000001"},
+		// Variables skip the reserved IO_BUFFER region at 16-31
+		{"@i", "0000000000100000"},
+		{"@j", "0000000000100001"},
+	}
+	for _, test := range tests {
+		instr, expected := test[0], test[1]
+		actual, err := assembler.encodeAInstruction(instr)
+		if err != nil || actual != expected {
+			t.Errorf("Encoding %s: Actual: %s (%v) != Expected: %s", instr, actual, err, expected)
+		}
+	}
+}
+
+func TestMemoryMap_Validate(t *testing.T) {
+	tests := map[string]*MemoryMap{
+		"overlaps":       {Regions: []MemoryRegion{{Name: "A", Start: 100, End: 200}, {Name: "B", Start: 200, End: 300}}},
+		"within 0":       {Regions: []MemoryRegion{{Name: "A", Start: 300, End: 200}}},
+		"invalid symbol": {Symbols: map[string]int{"1ABC": 5}},
+		"address":        {Symbols: map[string]int{"ABC": 40000}},
+	}
+	for expected, memoryMap := range tests {
+		err := memoryMap.Validate()
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing (%s), got: %v", expected, err)
+		}
+	}
+
+	if err := DefaultMemoryMap().Validate(); err != nil {
+		t.Errorf("Default memory map should be valid: %v", err)
+	}
+}
+
+func TestAssembler_AssembleReportsAllErrors(t *testing.T) {
+	asmLines := toSourceLines([]string{"@0", "D=Q", "(LOOP)", "AM=M+2", "(LOOP)", "@99999", "D;JMPX", "(SP)"})
+	_, err := NewAssembler().Assemble(asmLines)
+	if err == nil {
+		t.Fatal("Expected errors")
+	}
+
+	expected := []string{
+		"test.asm:5: label (LOOP) already defined at test.asm:3",
+		"test.asm:8: label (SP) redefines a predefined symbol",
+		"test.asm:2: C-Instruction (D=Q) has unknown comp (Q)",
+		"test.asm:4: C-Instruction (AM=M+2) has unknown comp (M+2)",
+		"test.asm:6: A-Instruction (@99999) constant must be in the range 0 ... 32767",
+		"test.asm:7: C-Instruction (D;JMPX) has unknown jump (JMPX)",
+	}
+	assertStringSlicesEqual(t, expected, strings.Split(err.Error(), "\n"))
+}
+
+func TestCheckCInstruction(t *testing.T) {
+	for _, instr := range []string{"M=-1", "D;JGT", "AMD=D|M;JMP", "0;JMP"} {
+		if err := checkCInstruction(instr); err != nil {
+			t.Errorf("Unexpected error for %s: %v", instr, err)
+		}
+	}
+	for _, instr := range []string{"X=D", "D=D+2", "D;JXX", "D=M;JMP;JMP", "D;JMP=M"} {
+		if err := checkCInstruction(instr); err == nil {
+			t.Errorf("Expected error for %s", instr)
+		}
+	}
+}
+
+func TestDecodeInstruction(t *testing.T) {
+	decoded := DecodeInstruction("1111110010001000")
+	if decoded.Type != "C" || decoded.Word != 64648 || decoded.Dest != "M" || decoded.Comp != "M-1" ||
+		decoded.Jump != "" || *decoded.ABit != 1 || decoded.Value != nil {
+		t.Errorf("Unexpected decoding of M=M-1: %+v", decoded)
+	}
+
+	decoded = DecodeInstruction("1110001100000001")
+	if decoded.Dest != "" || decoded.Comp != "D" || decoded.Jump != "JGT" || *decoded.ABit != 0 {
+		t.Errorf("Unexpected decoding of D;JGT: %+v", decoded)
+	}
+
+	decoded = DecodeInstruction("0100000000000000")
+	if decoded.Type != "A" || *decoded.Value != 16384 || decoded.ABit != nil {
+		t.Errorf("Unexpected decoding of @SCREEN: %+v", decoded)
+	}
+}
+
+func TestAssembler_ProgramJSON(t *testing.T) {
+	assembler := NewAssembler()
+	if err := runOnCopy(t, assembler, "Rect.asm"); err != nil {
+		t.Fatal(err)
+	}
+	programJSON := assembler.ProgramJSON()
+
+	if len(programJSON.Instructions) != 25 {
+		t.Fatalf("Expected 25 instructions, got %v", len(programJSON.Instructions))
+	}
+	// (LOOP) is at ROM address 10, line 20 is @address
+	loopStart := programJSON.Instructions[10]
+	if loopStart.Address != 10 || loopStart.Source.Line != 20 || loopStart.Source.Text != "@address" || *loopStart.Value != 17 {
+		t.Errorf("Unexpected instruction: %+v", loopStart)
+	}
+
+	kinds := map[string]SymbolKind{}
+	for _, symbol := range programJSON.Symbols {
+		kinds[symbol.Name] = symbol.Kind
+	}
+	if len(kinds) != 27 || kinds["LOOP"] != LabelSymbol || kinds["counter"] != VariableSymbol || kinds["R1"] != PredefinedSymbol {
+		t.Errorf("Unexpected symbol table: %+v", programJSON.Symbols)
+	}
+}
+
+func TestAssembler_SourceMap(t *testing.T) {
+	// Assemble rather than Run, which would write Test1.hack next to the source
+	lines, err := readASMSourceLines("Test1.asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assembler := NewAssembler()
+	if _, err := assembler.Assemble(lines); err != nil {
+		t.Fatal(err)
+	}
+	sourceMap := assembler.SourceMap("Test1.hack")
+
+	// Test1.asm has Windows line endings and an indented last line
+	expected := []SourceMapEntry{
+		{Generated: 0, File: "Test1.asm", Line: 4, Column: 1},
+		{Generated: 1, File: "Test1.asm", Line: 5, Column: 1},
+		{Generated: 2, File: "Test1.asm", Line: 6, Column: 1},
+		{Generated: 3, File: "Test1.asm", Line: 7, Column: 1},
+		{Generated: 4, File: "Test1.asm", Line: 9, Column: 1},
+		{Generated: 5, File: "Test1.asm", Line: 10, Column: 3},
+	}
+	assertSourceMapEqual(t, expected, sourceMap.Mappings)
+
+	// The map the VM translator would write if Test1.asm came from Add.vm,
+	// round tripped through the file format
+	mapFile := filepath.Join(t.TempDir(), "Test1.asm.map")
+	file, err := os.Create(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = (&SourceMap{Version: SourceMapVersion, File: "Test1.asm", Mappings: []SourceMapEntry{
+		{Generated: 4, File: "Add.vm", Line: 1, Column: 1},
+		{Generated: 5, File: "Add.vm", Line: 1, Column: 1},
+		{Generated: 6, File: "Add.vm", Line: 2, Column: 3},
+		{Generated: 7, File: "Add.vm", Line: 3, Column: 1},
+	}}).WriteJSON(file)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := LoadSourceMap(mapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []SourceMapEntry{
+		{Generated: 0, File: "Add.vm", Line: 1, Column: 1},
+		{Generated: 1, File: "Add.vm", Line: 1, Column: 1},
+		{Generated: 2, File: "Add.vm", Line: 2, Column: 3},
+		{Generated: 3, File: "Add.vm", Line: 3, Column: 1},
+		{Generated: 4, File: "Test1.asm", Line: 9, Column: 1},
+		{Generated: 5, File: "Test1.asm", Line: 10, Column: 3},
+	}
+	assertSourceMapEqual(t, expected, sourceMap.Chain(input).Mappings)
+}
+
+func assertSourceMapEqual(t *testing.T, expected, actual []SourceMapEntry) {
+	if len(expected) != len(actual) {
+		t.Fatalf("Actual: %+v != Expected: %+v", actual, expected)
+	}
+	for i, entry := range expected {
+		if actual[i] != entry {
+			t.Errorf("Actual: %+v != Expected: %+v", actual[i], entry)
+		}
+	}
+}
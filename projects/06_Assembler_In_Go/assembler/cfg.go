@@ -0,0 +1,254 @@
+package assembler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Edge target used for jumps whose destination can't be determined statically, e.g. A=M; 0;JMP
+const UnknownBlock = -1
+
+type EdgeKind string
+
+const (
+	FallthroughEdge EdgeKind = "fallthrough"
+	JumpEdge        EdgeKind = "jump"     // unconditional jump
+	BranchEdge      EdgeKind = "branch"   // conditional jump, taken
+	ComputedEdge    EdgeKind = "computed" // jump to an address computed at runtime
+)
+
+// A maximal run of instructions only entered at Start and only left after End (inclusive)
+type BasicBlock struct {
+	ID               int      `json:"id"`
+	Labels           []string `json:"labels"`
+	Start            int      `json:"start"`
+	End              int      `json:"end"`
+	InstructionCount int      `json:"instructionCount"`
+}
+
+type CFGEdge struct {
+	From int      `json:"from"`
+	To   int      `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+type ControlFlowGraph struct {
+	Blocks []BasicBlock `json:"blocks"`
+	Edges  []CFGEdge    `json:"edges"`
+}
+
+// Builds the control-flow graph of the program assembled by Run. Jumps to
+// an address past the end of the program are errors.
+func (a *Assembler) ControlFlowGraph() (*ControlFlowGraph, error) {
+	labels := map[int][]string{}
+	for _, info := range a.symbols {
+		if info.Kind == LabelSymbol {
+			labels[info.Value] = append(labels[info.Value], info.Name)
+		}
+	}
+	for _, names := range labels {
+		sort.Strings(names)
+	}
+
+	return buildControlFlowGraph(a.program, labels)
+}
+
+// The effect of a single instruction on control flow
+type jumpInfo struct {
+	isJump        bool
+	unconditional bool
+	target        int // UnknownBlock if computed
+}
+
+func buildControlFlowGraph(program []ProgramInstruction, labels map[int][]string) (*ControlFlowGraph, error) {
+	cfg := &ControlFlowGraph{Blocks: []BasicBlock{}, Edges: []CFGEdge{}}
+	if len(program) == 0 {
+		return cfg, nil
+	}
+
+	// The A register can only be tracked within a block, but the blocks
+	// depend on the jump targets. Tracking it with A reset at the labels
+	// finds every possible leader. Tracking it again with A reset at all
+	// of those can only resolve fewer jumps, and none of them wrongly.
+	initial := map[int]bool{}
+	for address := range labels {
+		initial[address] = true
+	}
+	jumps := analyseJumps(program, initial)
+	jumps = analyseJumps(program, findLeaders(program, labels, jumps))
+	leaders := findLeaders(program, labels, jumps)
+
+	errs := []error{}
+	for address, jump := range jumps {
+		if jump.isJump && jump.target >= len(program) {
+			errs = append(errs, newSourceError(program[address].Source,
+				"jump to ROM address %v, beyond the end of the program at %v", jump.target, len(program)-1))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	blockAt := map[int]int{}
+	for address := range program {
+		if leaders[address] {
+			blockLabels := labels[address]
+			if blockLabels == nil {
+				blockLabels = []string{}
+			}
+			blockAt[address] = len(cfg.Blocks)
+			cfg.Blocks = append(cfg.Blocks, BasicBlock{ID: len(cfg.Blocks), Labels: blockLabels, Start: address})
+		}
+		block := &cfg.Blocks[len(cfg.Blocks)-1]
+		block.End = address
+		block.InstructionCount++
+	}
+
+	for _, block := range cfg.Blocks {
+		last := jumps[block.End]
+		nextBlock, hasNext := blockAt[block.End+1]
+
+		if last.isJump {
+			kind := BranchEdge
+			if last.unconditional {
+				kind = JumpEdge
+			}
+			target, known := blockAt[last.target]
+			if last.target == UnknownBlock || !known {
+				target = UnknownBlock
+				kind = ComputedEdge
+			}
+			cfg.Edges = append(cfg.Edges, CFGEdge{From: block.ID, To: target, Kind: kind})
+		}
+		if hasNext && !(last.isJump && last.unconditional) {
+			cfg.Edges = append(cfg.Edges, CFGEdge{From: block.ID, To: nextBlock, Kind: FallthroughEdge})
+		}
+	}
+
+	return cfg, nil
+}
+
+// Block leaders: the first instruction, every label, every instruction
+// following a jump and every statically known jump target
+func findLeaders(program []ProgramInstruction, labels map[int][]string, jumps []jumpInfo) map[int]bool {
+	leaders := map[int]bool{0: true}
+	for address := range labels {
+		if address < len(program) {
+			leaders[address] = true
+		}
+	}
+	for address, jump := range jumps {
+		if !jump.isJump {
+			continue
+		}
+		if address+1 < len(program) {
+			leaders[address+1] = true
+		}
+		if jump.target != UnknownBlock && jump.target < len(program) {
+			leaders[jump.target] = true
+		}
+	}
+	return leaders
+}
+
+// Determines, for each instruction, whether it jumps and where to.
+// The A register is tracked through straight-line code so that the
+// usual @LABEL; 0;JMP pattern resolves, it's unknown at every possible
+// block leader and after any instruction whose destination includes A,
+// e.g. A=M.
+func analyseJumps(program []ProgramInstruction, leaders map[int]bool) []jumpInfo {
+	jumps := make([]jumpInfo, len(program))
+	aRegister := UnknownBlock
+
+	for address, instr := range program {
+		if leaders[address] {
+			aRegister = UnknownBlock
+		}
+
+		word := instr.Word
+		if word[0] == '0' {
+			value, _ := strconv.ParseInt(word[1:], 2, 16)
+			aRegister = int(value)
+			continue
+		}
+
+		comp, dest, jump := word[3:10], word[10:13], word[13:16]
+		if jump != "000" {
+			taken, constant := constantJumpTaken(comp, jump)
+			if !constant || taken {
+				jumps[address] = jumpInfo{isJump: true, unconditional: constant, target: aRegister}
+			}
+			aRegister = UnknownBlock
+		}
+		if dest[0] == '1' {
+			aRegister = UnknownBlock
+		}
+	}
+
+	return jumps
+}
+
+// If the jump doesn't depend on comp, i.e. it's JMP or comp is a constant
+// (0, 1 or -1), reports whether it's always taken
+func constantJumpTaken(comp string, jump string) (bool, bool) {
+	if jump == "111" {
+		return true, true
+	}
+
+	var value int
+	switch comp {
+	case "0101010":
+		value = 0
+	case "0111111":
+		value = 1
+	case "0111010":
+		value = -1
+	default:
+		return false, false
+	}
+
+	taken := (jump[0] == '1' && value < 0) || (jump[1] == '1' && value == 0) || (jump[2] == '1' && value > 0)
+	return taken, true
+}
+
+func (cfg *ControlFlowGraph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph CFG {\n")
+	sb.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	hasUnknown := false
+	for _, block := range cfg.Blocks {
+		name := fmt.Sprintf("B%v", block.ID)
+		if len(block.Labels) > 0 {
+			name += " (" + strings.Join(block.Labels, ", ") + ")"
+		}
+		fmt.Fprintf(&sb, "  b%v [label=%q];\n", block.ID,
+			fmt.Sprintf("%s\n%v-%v (%v instructions)", name, block.Start, block.End, block.InstructionCount))
+	}
+	for _, edge := range cfg.Edges {
+		to := fmt.Sprintf("b%v", edge.To)
+		if edge.To == UnknownBlock {
+			to = "unknown"
+			hasUnknown = true
+		}
+		fmt.Fprintf(&sb, "  b%v -> %s [label=%q];\n", edge.From, to, edge.Kind)
+	}
+	if hasUnknown {
+		sb.WriteString("  unknown [shape=diamond, label=\"?\"];\n")
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (cfg *ControlFlowGraph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cfg)
+}
@@ -0,0 +1,104 @@
+package assembler
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// The canonical mnemonic for each set of dest bits, as several spellings share the same bits
+var canonicalDest = map[string]string{
+	"001": "M",
+	"010": "D",
+	"011": "MD",
+	"100": "A",
+	"101": "AM",
+	"110": "AD",
+	"111": "AMD",
+}
+
+// An assembled instruction with its fields decoded, for external tools.
+// Value is only set for A-Instructions, Dest/Comp/Jump/ABit for C-Instructions,
+// Dest and Jump are empty when the instruction has none.
+type DecodedInstruction struct {
+	Address int        `json:"address"`
+	Word    uint16     `json:"word"`
+	Binary  string     `json:"binary"`
+	Type    string     `json:"type"`
+	Value   *int       `json:"value,omitempty"`
+	Dest    string     `json:"dest,omitempty"`
+	Comp    string     `json:"comp,omitempty"`
+	Jump    string     `json:"jump,omitempty"`
+	ABit    *int       `json:"a,omitempty"`
+	Source  SourceLine `json:"source"`
+}
+
+type SymbolTableEntry struct {
+	Name  string     `json:"name"`
+	Kind  SymbolKind `json:"kind"`
+	Value int        `json:"value"`
+}
+
+type ProgramJSON struct {
+	Instructions []DecodedInstruction `json:"instructions"`
+	Symbols      []SymbolTableEntry   `json:"symbols"`
+}
+
+// Decodes a 16 character binary word, e.g. 1111110010001000 -> C-Instruction M=M-1
+func DecodeInstruction(binary string) DecodedInstruction {
+	word, _ := strconv.ParseUint(binary, 2, 16)
+	decoded := DecodedInstruction{Word: uint16(word), Binary: binary}
+
+	if binary[0] == '0' {
+		value := int(word)
+		decoded.Type = "A"
+		decoded.Value = &value
+		return decoded
+	}
+
+	aBit := int(binary[3] - '0')
+	decoded.Type = "C"
+	decoded.ABit = &aBit
+	decoded.Dest = canonicalDest[binary[10:13]]
+	for mnemonic, bits := range jumpBitsMap {
+		if bits == binary[13:16] {
+			decoded.Jump = mnemonic
+		}
+	}
+	for mnemonic, bits := range compBitsMap {
+		if bits == binary[3:10] {
+			decoded.Comp = mnemonic
+		}
+	}
+	return decoded
+}
+
+// The program assembled by Run along with the final symbol table
+func (a *Assembler) ProgramJSON() ProgramJSON {
+	programJSON := ProgramJSON{Instructions: []DecodedInstruction{}, Symbols: []SymbolTableEntry{}}
+
+	for _, instr := range a.program {
+		decoded := DecodeInstruction(instr.Word)
+		decoded.Address = instr.Address
+		decoded.Source = instr.Source
+		programJSON.Instructions = append(programJSON.Instructions, decoded)
+	}
+
+	for symbol, value := range a.SymbolMap {
+		kind := PredefinedSymbol
+		if info, exists := a.symbols[symbol]; exists {
+			kind = info.Kind
+		}
+		programJSON.Symbols = append(programJSON.Symbols, SymbolTableEntry{Name: symbol, Kind: kind, Value: value})
+	}
+	sort.Slice(programJSON.Symbols, func(i, j int) bool { return programJSON.Symbols[i].Name < programJSON.Symbols[j].Name })
+
+	return programJSON
+}
+
+func (a *Assembler) WriteProgramJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a.ProgramJSON())
+}
@@ -0,0 +1,156 @@
+package assembler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hack character set codes for the supported escape sequences,
+// newline and backspace are the Hack keyboard codes rather than ASCII
+var escapeCodes = map[byte]int{
+	'n':  128,
+	'b':  129,
+	'\\': '\\',
+	'\'': '\'',
+	'"':  '"',
+}
+
+// The printable range of the Hack character set (same as ASCII)
+const (
+	minPrintableChar = 32
+	maxPrintableChar = 126
+)
+
+// e.g. 'A' -> 65, '\n' -> 128
+func parseCharLiteral(literal string) (int, error) {
+	if len(literal) < 3 || literal[0] != '\'' || literal[len(literal)-1] != '\'' {
+		return 0, fmt.Errorf("invalid character literal: %s", literal)
+	}
+
+	codes, err := decodeCharacters(literal[1 : len(literal)-1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid character literal: %s, %v", literal, err)
+	}
+	if len(codes) != 1 {
+		return 0, fmt.Errorf("character literal must contain exactly one character: %s", literal)
+	}
+	return codes[0], nil
+}
+
+// e.g. "Hi\n" -> [72 105 128]
+func parseStringLiteral(literal string) ([]int, error) {
+	if len(literal) < 2 || literal[0] != '"' || literal[len(literal)-1] != '"' {
+		return nil, fmt.Errorf("invalid string literal: %s", literal)
+	}
+
+	codes, err := decodeCharacters(literal[1 : len(literal)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid string literal: %s, %v", literal, err)
+	}
+	return codes, nil
+}
+
+// Converts the body of a character/string literal to Hack character codes
+func decodeCharacters(body string) ([]int, error) {
+	codes := []int{}
+
+	for i := 0; i < len(body); i++ {
+		char := body[i]
+
+		if char == '\\' {
+			if i+1 >= len(body) {
+				return nil, fmt.Errorf("unterminated escape sequence")
+			}
+			i++
+			code, ok := escapeCodes[body[i]]
+			if !ok {
+				return nil, fmt.Errorf("unknown escape sequence: \\%c", body[i])
+			}
+			codes = append(codes, code)
+			continue
+		}
+
+		if char < minPrintableChar || char > maxPrintableChar {
+			return nil, fmt.Errorf("character (%q) is not in the Hack character set", char)
+		}
+		codes = append(codes, int(char))
+	}
+	return codes, nil
+}
+
+// Expands assembler directives into regular instructions, currently only:
+//
+//	.string ADDR "text"
+//
+// which stores the characters of text into RAM[ADDR], RAM[ADDR+1], ...
+// ADDR must be a constant or a predefined symbol, e.g. .string 1024 "Hello\n"
+func (a *Assembler) expandDirectives(asmLines []SourceLine) ([]SourceLine, error) {
+	errs := []error{}
+	expandedLines := []SourceLine{}
+
+	for _, line := range asmLines {
+		if !strings.HasPrefix(line.Text, ".") {
+			expandedLines = append(expandedLines, line)
+			continue
+		}
+
+		directive, args, _ := strings.Cut(line.Text, " ")
+		switch directive {
+		case ".string":
+			lines, err := a.expandStringDirective(strings.TrimSpace(args))
+			if err != nil {
+				errs = append(errs, newSourceError(line, "%s: %v", line.Text, err))
+				continue
+			}
+			// The generated instructions all map back to the directive's line
+			for _, text := range lines {
+				expandedLines = append(expandedLines, SourceLine{File: line.File, Line: line.Line, Column: line.Column, Text: text})
+			}
+		default:
+			errs = append(errs, newSourceError(line, "unknown directive: %s", line.Text))
+		}
+	}
+
+	return expandedLines, errors.Join(errs...)
+}
+
+func (a *Assembler) expandStringDirective(args string) ([]string, error) {
+	addressArg, literal, found := strings.Cut(args, " ")
+	if !found {
+		return nil, fmt.Errorf(`expected form: .string ADDR "text"`)
+	}
+
+	var baseAddress int
+	if isConstant(addressArg) {
+		constant, err := strconv.ParseInt(addressArg, 0, 16)
+		if err != nil || constant < 0 {
+			return nil, fmt.Errorf("address (%s) must be in the range 0 ... %v", addressArg, MaxAddress)
+		}
+		baseAddress = int(constant)
+	} else {
+		address, exists := a.SymbolMap[addressArg]
+		if !exists {
+			return nil, fmt.Errorf("address (%s) must be a constant or predefined symbol", addressArg)
+		}
+		baseAddress = address
+	}
+
+	codes, err := parseStringLiteral(strings.TrimSpace(literal))
+	if err != nil {
+		return nil, err
+	}
+	if baseAddress+len(codes)-1 > MaxAddress {
+		return nil, fmt.Errorf("string of length %v at address %v extends beyond address %v", len(codes), baseAddress, MaxAddress)
+	}
+
+	lines := []string{}
+	for i, code := range codes {
+		lines = append(lines, fmt.Sprintf("@%v", code))
+		lines = append(lines, "D=A")
+		lines = append(lines, fmt.Sprintf("@%v", baseAddress+i))
+		lines = append(lines, "M=D")
+	}
+	return lines, nil
+}
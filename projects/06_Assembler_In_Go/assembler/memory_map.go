@@ -0,0 +1,142 @@
+package assembler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// A range of RAM addresses (inclusive) reserved for registers or a device,
+// variables are never allocated inside a reserved region
+type MemoryRegion struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// The predefined symbols and reserved regions of the target hardware, e.g.
+//
+//	{
+//	  "symbols": {"TIMER": 24577, "SERIAL": 24578},
+//	  "regions": [{"name": "TIMER", "start": 24577, "end": 24577}]
+//	}
+type MemoryMap struct {
+	Symbols       map[string]int `json:"symbols"`
+	Regions       []MemoryRegion `json:"regions"`
+	VariableStart int            `json:"variableStart"`
+}
+
+// The standard Hack memory map
+func DefaultMemoryMap() *MemoryMap {
+	symbols := map[string]int{
+		"SP": 0, "LCL": 1, "ARG": 2, "THIS": 3, "THAT": 4, "SCREEN": 16384, "KBD": 24576,
+	}
+
+	// R0 ... R15
+	for i := 0; i <= 15; i++ {
+		key := "R" + strconv.FormatInt(int64(i), 10)
+		symbols[key] = i
+	}
+
+	regions := []MemoryRegion{
+		{Name: "R0-R15", Start: 0, End: 15},
+		{Name: "SCREEN", Start: 16384, End: 24575},
+		{Name: "KBD", Start: 24576, End: 24576},
+	}
+
+	return &MemoryMap{Symbols: symbols, Regions: regions, VariableStart: 16}
+}
+
+// Reads a memory map file, adding its symbols and regions to the standard
+// Hack memory map. The file may override variableStart but not redefine
+// a standard symbol with a different address.
+func LoadMemoryMap(filename string) (*MemoryMap, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := &MemoryMap{}
+	if err := json.Unmarshal(contents, extension); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	memoryMap := DefaultMemoryMap()
+	for symbol, address := range extension.Symbols {
+		existing, exists := memoryMap.Symbols[symbol]
+		if exists && existing != address {
+			return nil, fmt.Errorf("%s: symbol (%s) redefined as %v, it's predefined as %v", filename, symbol, address, existing)
+		}
+		memoryMap.Symbols[symbol] = address
+	}
+	memoryMap.Regions = append(memoryMap.Regions, extension.Regions...)
+	if extension.VariableStart != 0 {
+		memoryMap.VariableStart = extension.VariableStart
+	}
+
+	if err := memoryMap.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return memoryMap, nil
+}
+
+func (m *MemoryMap) Validate() error {
+	for symbol, address := range m.Symbols {
+		if !isValidSymbol(symbol) {
+			return fmt.Errorf("invalid symbol name: (%s)", symbol)
+		}
+		if address < 0 || address > MaxAddress {
+			return fmt.Errorf("symbol (%s) address %v must be in the range 0 ... %v", symbol, address, MaxAddress)
+		}
+	}
+
+	regions := append([]MemoryRegion{}, m.Regions...)
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Start < regions[j].Start })
+
+	for i, region := range regions {
+		if region.Name == "" {
+			return fmt.Errorf("region %v-%v must have a name", region.Start, region.End)
+		}
+		if region.Start < 0 || region.End > MaxAddress || region.Start > region.End {
+			return fmt.Errorf("region (%s) %v-%v must be a range within 0 ... %v", region.Name, region.Start, region.End, MaxAddress)
+		}
+		if i > 0 && region.Start <= regions[i-1].End {
+			previous := regions[i-1]
+			return fmt.Errorf("region (%s) %v-%v overlaps region (%s) %v-%v",
+				region.Name, region.Start, region.End, previous.Name, previous.Start, previous.End)
+		}
+	}
+
+	if m.VariableStart < 0 || m.VariableStart > MaxAddress {
+		return fmt.Errorf("variableStart %v must be in the range 0 ... %v", m.VariableStart, MaxAddress)
+	}
+	return nil
+}
+
+// Returns the first address >= address that isn't in a reserved region
+func (m *MemoryMap) nextFreeAddress(address int) int {
+	for _, region := range m.Regions {
+		if address >= region.Start && address <= region.End {
+			// Regions don't overlap, but may be adjacent
+			return m.nextFreeAddress(region.End + 1)
+		}
+	}
+	return address
+}
+
+// Symbols are made up of letters, digits, _ . $ : and may not begin with a digit
+func isValidSymbol(symbol string) bool {
+	if symbol == "" || (symbol[0] >= '0' && symbol[0] <= '9') {
+		return false
+	}
+	for _, char := range symbol {
+		isLetter := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+		isDigit := char >= '0' && char <= '9'
+		if !isLetter && !isDigit && char != '_' && char != '.' && char != '$' && char != ':' {
+			return false
+		}
+	}
+	return true
+}
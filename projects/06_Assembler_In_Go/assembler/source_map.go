@@ -0,0 +1,85 @@
+package assembler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The version of the source map format written and read
+const SourceMapVersion = 1
+
+// Where a generated position came from. Generated is the ROM address in a
+// .hack source map, or the line number in a source map for a generated .asm
+// file (e.g. one written by the VM translator), Line and Column are 1-based
+type SourceMapEntry struct {
+	Generated int    `json:"generated"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+}
+
+type SourceMap struct {
+	Version  int              `json:"version"`
+	File     string           `json:"file"`
+	Mappings []SourceMapEntry `json:"mappings"`
+}
+
+// Maps each ROM address of the program assembled by Run back to its .asm source
+func (a *Assembler) SourceMap(hackFile string) *SourceMap {
+	sourceMap := &SourceMap{Version: SourceMapVersion, File: hackFile, Mappings: []SourceMapEntry{}}
+	for _, instr := range a.program {
+		sourceMap.Mappings = append(sourceMap.Mappings, SourceMapEntry{
+			Generated: instr.Address,
+			File:      instr.Source.File,
+			Line:      instr.Source.Line,
+			Column:    instr.Source.Column,
+		})
+	}
+	return sourceMap
+}
+
+// Returns a copy of the map where every entry pointing into input's generated
+// file is replaced by where input says that line came from, so a .hack map
+// chained with the VM translator's .asm map points back to the .vm source.
+// Entries with no corresponding line in input are kept as they are.
+func (m *SourceMap) Chain(input *SourceMap) *SourceMap {
+	origins := map[int]SourceMapEntry{}
+	for _, entry := range input.Mappings {
+		origins[entry.Generated] = entry
+	}
+
+	chained := &SourceMap{Version: m.Version, File: m.File, Mappings: []SourceMapEntry{}}
+	for _, entry := range m.Mappings {
+		origin, exists := origins[entry.Line]
+		if exists && filepath.Base(entry.File) == filepath.Base(input.File) {
+			entry.File, entry.Line, entry.Column = origin.File, origin.Line, origin.Column
+		}
+		chained.Mappings = append(chained.Mappings, entry)
+	}
+	return chained
+}
+
+func LoadSourceMap(filename string) (*SourceMap, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceMap := &SourceMap{}
+	if err := json.Unmarshal(contents, sourceMap); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if sourceMap.Version != SourceMapVersion {
+		return nil, fmt.Errorf("%s: unsupported source map version %v", filename, sourceMap.Version)
+	}
+	return sourceMap, nil
+}
+
+func (m *SourceMap) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
@@ -0,0 +1,30 @@
+package assembler
+
+import "fmt"
+
+// ROM usage (as a fraction) above which a program is considered close to the limit
+const romWarningThreshold = 0.9
+
+type SizeSummary struct {
+	Instructions int
+	Labels       int
+	Variables    int
+}
+
+func (a *Assembler) Summary() SizeSummary {
+	return SizeSummary{Instructions: a.numInstructions, Labels: a.numLabels, Variables: a.numVariables}
+}
+
+// Percentage of the ROM used by the program's instructions
+func (s SizeSummary) ROMUsage() float64 {
+	return 100 * float64(s.Instructions) / float64(ROMSize)
+}
+
+func (s SizeSummary) NearLimit() bool {
+	return float64(s.Instructions) >= romWarningThreshold*float64(ROMSize)
+}
+
+func (s SizeSummary) String() string {
+	return fmt.Sprintf("Instructions: %v/%v (%.2f%% of ROM), Labels: %v, Variables: %v",
+		s.Instructions, ROMSize, s.ROMUsage(), s.Labels, s.Variables)
+}
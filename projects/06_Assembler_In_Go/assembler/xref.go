@@ -0,0 +1,90 @@
+package assembler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type SymbolKind string
+
+const (
+	PredefinedSymbol SymbolKind = "predefined"
+	LabelSymbol      SymbolKind = "label"
+	VariableSymbol   SymbolKind = "variable"
+)
+
+// Cross-reference entry for a symbol, Definition is nil for predefined symbols
+type SymbolInfo struct {
+	Name       string       `json:"name"`
+	Kind       SymbolKind   `json:"kind"`
+	Value      int          `json:"value"`
+	Definition *SourceLine  `json:"definition,omitempty"`
+	Uses       []SourceLine `json:"uses"`
+}
+
+func (a *Assembler) defineSymbol(symbol string, kind SymbolKind, value int, line SourceLine) {
+	info, exists := a.symbols[symbol]
+	if !exists {
+		info = &SymbolInfo{Name: symbol}
+		a.symbols[symbol] = info
+	}
+	info.Kind = kind
+	info.Value = value
+	info.Definition = &line
+}
+
+func (a *Assembler) useSymbol(symbol string, line SourceLine) {
+	info := a.symbols[symbol]
+	info.Uses = append(info.Uses, line)
+}
+
+// Returns the symbols collected while assembling, sorted by name.
+// Predefined symbols are only included if the program uses them.
+func (a *Assembler) CrossReference() []SymbolInfo {
+	xref := []SymbolInfo{}
+	for _, info := range a.symbols {
+		if info.Kind == PredefinedSymbol && len(info.Uses) == 0 {
+			continue
+		}
+		entry := *info
+		if entry.Uses == nil {
+			entry.Uses = []SourceLine{}
+		}
+		xref = append(xref, entry)
+	}
+
+	sort.Slice(xref, func(i, j int) bool { return xref[i].Name < xref[j].Name })
+	return xref
+}
+
+// One line per symbol with the line numbers it's used on, e.g.
+// LOOP    label    10  defined: Rect.asm:19    used: 31
+func WriteCrossReferenceText(w io.Writer, xref []SymbolInfo) error {
+	for _, info := range xref {
+		definition := "-"
+		if info.Definition != nil {
+			definition = info.Definition.Position()
+		}
+
+		uses := []string{}
+		for _, use := range info.Uses {
+			uses = append(uses, fmt.Sprintf("%v", use.Line))
+		}
+
+		_, err := fmt.Fprintf(w, "%-30s %-10s %6v  defined: %-20s used: %s\n",
+			info.Name, info.Kind, info.Value, definition, strings.Join(uses, ","))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WriteCrossReferenceJSON(w io.Writer, xref []SymbolInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(xref)
+}
@@ -0,0 +1,244 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+// An open .asm file, re-assembled from scratch on every change
+type document struct {
+	uri       string
+	lines     []string // Raw text, one entry per line
+	assembler *assembler.Assembler
+	symbols   map[string]assembler.SymbolInfo
+	errs      []error
+}
+
+func analyse(uri string, text string, memoryMap *assembler.MemoryMap) *document {
+	doc := &document{uri: uri, lines: strings.Split(text, "\n"), symbols: map[string]assembler.SymbolInfo{}}
+
+	doc.assembler = assembler.NewAssemblerWithMemoryMap(memoryMap)
+	doc.assembler.DebugOutput = io.Discard
+
+	sourceLines, err := assembler.ParseSourceLines(uri, strings.NewReader(text))
+	if err == nil {
+		_, err = doc.assembler.Assemble(sourceLines)
+	}
+	doc.errs = flattenErrors(err)
+
+	for _, info := range doc.assembler.CrossReference() {
+		doc.symbols[info.Name] = info
+	}
+	return doc
+}
+
+// Splits errors.Join trees back into the individual errors
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := []error{}
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, flattenErrors(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
+
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, err := range d.errs {
+		diagnostic := Diagnostic{Severity: SeverityError, Source: "hack-asm", Message: err.Error()}
+
+		var sourceErr *assembler.SourceError
+		if errors.As(err, &sourceErr) {
+			diagnostic.Message = sourceErr.Message
+			diagnostic.Range = d.lineRange(sourceErr.Source)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// The range covering the (trimmed) text of a source line
+func (d *document) lineRange(line assembler.SourceLine) Range {
+	start := line.Column - 1
+	return Range{Start: d.position(line.Line-1, start), End: d.position(line.Line-1, start+len(line.Text))}
+}
+
+// The range of the symbol name within @SYMBOL or (SYMBOL)
+func (d *document) symbolRange(line assembler.SourceLine, symbol string) Range {
+	start := line.Column
+	return Range{Start: d.position(line.Line-1, start), End: d.position(line.Line-1, start+len(symbol))}
+}
+
+// LSP positions count UTF-16 code units along the line, where the
+// assembler counts bytes. Returns the line and the byte offset of the
+// position within it, or false if it's outside the document.
+func (d *document) offset(pos Position) (string, int, bool) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) || pos.Character < 0 {
+		return "", 0, false
+	}
+	line := d.lines[pos.Line]
+
+	units := 0
+	for offset, char := range line {
+		if units >= pos.Character {
+			return line, offset, units == pos.Character
+		}
+		units += utf16.RuneLen(char)
+	}
+	return line, len(line), units == pos.Character
+}
+
+// The LSP position of a byte offset within a line
+func (d *document) position(line int, offset int) Position {
+	if line < 0 || line >= len(d.lines) || offset > len(d.lines[line]) {
+		return Position{Line: line, Character: offset}
+	}
+	units := 0
+	for _, char := range d.lines[line][:offset] {
+		units += utf16.RuneLen(char)
+	}
+	return Position{Line: line, Character: units}
+}
+
+func isSymbolChar(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') ||
+		char == '_' || char == '.' || char == '$' || char == ':'
+}
+
+// Returns the known symbol under the cursor, if any
+func (d *document) symbolAt(pos Position) (assembler.SymbolInfo, bool) {
+	line, offset, valid := d.offset(pos)
+	if !valid {
+		return assembler.SymbolInfo{}, false
+	}
+
+	start, end := offset, offset
+	for start > 0 && isSymbolChar(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isSymbolChar(line[end]) {
+		end++
+	}
+	if start >= end {
+		return assembler.SymbolInfo{}, false
+	}
+
+	info, exists := d.symbols[line[start:end]]
+	return info, exists
+}
+
+func (d *document) definition(pos Position) []Location {
+	info, exists := d.symbolAt(pos)
+	if !exists || info.Definition == nil {
+		return []Location{}
+	}
+	return []Location{{URI: d.uri, Range: d.symbolRange(*info.Definition, info.Name)}}
+}
+
+func (d *document) references(pos Position, includeDeclaration bool) []Location {
+	info, exists := d.symbolAt(pos)
+	if !exists {
+		return []Location{}
+	}
+
+	locations := []Location{}
+	// A variable is defined by its first use, so it's already in the uses
+	if includeDeclaration && info.Kind == assembler.LabelSymbol {
+		locations = append(locations, Location{URI: d.uri, Range: d.symbolRange(*info.Definition, info.Name)})
+	}
+	for _, use := range info.Uses {
+		locations = append(locations, Location{URI: d.uri, Range: d.symbolRange(use, info.Name)})
+	}
+	return locations
+}
+
+func (d *document) hover(pos Position) *Hover {
+	contents := []string{}
+
+	if info, exists := d.symbolAt(pos); exists {
+		contents = append(contents, fmt.Sprintf("**%s** (%s) = %v", info.Name, info.Kind, info.Value))
+	}
+	for _, instr := range d.assembler.Program() {
+		if instr.Source.Line == pos.Line+1 {
+			contents = append(contents, fmt.Sprintf("ROM[%v]: `%s`", instr.Address, instr.Word))
+		}
+	}
+
+	if len(contents) == 0 {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: strings.Join(contents, "\n\n")}}
+}
+
+// Completes symbols after @, comp after =, jump after ; and otherwise
+// offers both dest= and comp mnemonics
+func (d *document) completion(pos Position) []CompletionItem {
+	prefix := ""
+	if line, offset, valid := d.offset(pos); valid {
+		prefix = strings.TrimSpace(line[:offset])
+	}
+
+	dest, comp, jump := assembler.CInstructionMnemonics()
+	items := []CompletionItem{}
+
+	switch {
+	case strings.HasPrefix(prefix, "@"):
+		for _, info := range d.symbols {
+			kind := CompletionKindVariable
+			if info.Kind == assembler.LabelSymbol {
+				kind = CompletionKindReference
+			} else if info.Kind == assembler.PredefinedSymbol {
+				kind = CompletionKindConstant
+			}
+			items = append(items, CompletionItem{Label: info.Name, Kind: kind, Detail: fmt.Sprintf("%s = %v", info.Kind, info.Value)})
+		}
+	case strings.Contains(prefix, ";"):
+		items = append(items, mnemonicItems(jump, "", "jump")...)
+	case strings.Contains(prefix, "="):
+		items = append(items, mnemonicItems(comp, "", "comp")...)
+	default:
+		items = append(items, mnemonicItems(dest, "=", "dest")...)
+		items = append(items, mnemonicItems(comp, "", "comp")...)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+func mnemonicItems(mnemonics map[string]string, suffix string, part string) []CompletionItem {
+	items := []CompletionItem{}
+	for mnemonic, bits := range mnemonics {
+		items = append(items, CompletionItem{Label: mnemonic + suffix, Kind: CompletionKindKeyword, Detail: part + " " + bits})
+	}
+	return items
+}
+
+func (d *document) documentSymbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, info := range d.symbols {
+		if info.Kind != assembler.LabelSymbol {
+			continue
+		}
+		symbols = append(symbols, DocumentSymbol{
+			Name:           info.Name,
+			Detail:         fmt.Sprintf("ROM[%v]", info.Value),
+			Kind:           SymbolKindFunction,
+			Range:          d.lineRange(*info.Definition),
+			SelectionRange: d.symbolRange(*info.Definition, info.Name),
+		})
+	}
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Range.Start.Line < symbols[j].Range.Start.Line })
+	return symbols
+}
@@ -0,0 +1,244 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+// JSON-RPC error codes
+const (
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// The largest message body accepted, far beyond any real request, so a bad
+// Content-Length can't make the server allocate gigabytes
+const maxContentLength = 16 << 20
+
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+// The message's Content-Length is over maxContentLength. Its body isn't
+// read, so the stream can't be trusted past it.
+var ErrMessageTooLarge = errors.New("message too large")
+
+type request struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// A language server for Hack assembly, speaking JSON-RPC over a pair of streams (usually stdin/stdout)
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	memoryMap *assembler.MemoryMap
+	documents map[string]*document
+	shutdown  bool
+}
+
+func NewServer(in io.Reader, out io.Writer, memoryMap *assembler.MemoryMap) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, memoryMap: memoryMap, documents: map[string]*document{}}
+}
+
+// Handles messages until the client sends exit (or closes the input stream)
+func (s *Server) Serve() error {
+	for {
+		content, err := s.readMessage()
+		if errors.Is(err, ErrMessageTooLarge) {
+			// There's no id to respond to without reading the body
+			s.respond(nil, nil, &responseError{Code: codeInvalidRequest, Message: err.Error()})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		req := request{}
+		if err := json.Unmarshal(content, &req); err != nil {
+			return fmt.Errorf("invalid message: %v", err)
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, respErr := s.handle(req)
+		// Notifications (no id) never get a response
+		if req.ID != nil {
+			if err := s.respond(req.ID, result, respErr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) handle(req request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       1, // Full document on every change
+				"definitionProvider":     true,
+				"referencesProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{"triggerCharacters": []string{"@", "=", ";"}},
+			},
+			"serverInfo": map[string]string{"name": "hack-asm-lsp"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		params := DidOpenTextDocumentParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		params := DidChangeTextDocumentParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if len(params.ContentChanges) > 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		params := DidCloseTextDocumentParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		return nil, nil
+	case "textDocument/definition", "textDocument/hover", "textDocument/completion":
+		params := TextDocumentPositionParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc, exists := s.documents[params.TextDocument.URI]
+		if !exists {
+			return nil, nil
+		}
+		switch req.Method {
+		case "textDocument/definition":
+			return doc.definition(params.Position), nil
+		case "textDocument/hover":
+			if hover := doc.hover(params.Position); hover != nil {
+				return hover, nil
+			}
+			return nil, nil
+		default:
+			return doc.completion(params.Position), nil
+		}
+	case "textDocument/references":
+		params := ReferenceParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc, exists := s.documents[params.TextDocument.URI]
+		if !exists {
+			return nil, nil
+		}
+		return doc.references(params.Position, params.Context.IncludeDeclaration), nil
+	case "textDocument/documentSymbol":
+		params := DocumentSymbolParams{}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc, exists := s.documents[params.TextDocument.URI]
+		if !exists {
+			return nil, nil
+		}
+		return doc.documentSymbols(), nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method}
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// Re-analyses a document and publishes its diagnostics
+func (s *Server) update(uri string, text string) {
+	doc := analyse(uri, text, s.memoryMap)
+	s.documents[uri] = doc
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics()})
+}
+
+// Reads a single message body, framed by a Content-Length header
+func (s *Server) readMessage() ([]byte, error) {
+	contentLength := -1
+	for {
+		header, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimSpace(header)
+		if header == "" {
+			break
+		}
+
+		name, value, found := strings.Cut(header, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if contentLength, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %s", value)
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, errors.New("message is missing a Content-Length header")
+	}
+	if contentLength > maxContentLength {
+		return nil, fmt.Errorf("%w: Content-Length %v is over the limit of %v", ErrMessageTooLarge, contentLength, maxContentLength)
+	}
+
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(s.in, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func (s *Server) writeMessage(message map[string]any) error {
+	message["jsonrpc"] = "2.0"
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %v\r\n\r\n%s", len(content), content)
+	return err
+}
+
+func (s *Server) respond(id *json.RawMessage, result any, respErr *responseError) error {
+	if respErr != nil {
+		return s.writeMessage(map[string]any{"id": id, "error": respErr})
+	}
+	return s.writeMessage(map[string]any{"id": id, "result": result})
+}
+
+func (s *Server) notify(method string, params any) {
+	_ = s.writeMessage(map[string]any{"method": method, "params": params})
+}
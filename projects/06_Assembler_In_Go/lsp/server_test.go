@@ -0,0 +1,248 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+const testURI = "file:///test/Loop.asm"
+
+// Line numbers (0-based) are used in the requests below
+const testProgram = `// Counts down from 10
+@10
+D=A
+@i
+M=D
+(LOOP)
+@i
+MD=M-1
+@LOOP
+D;JGT
+D=Q
+(END)
+@END
+0;JMP`
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func frame(messages ...map[string]any) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		msg["jsonrpc"] = "2.0"
+		content, _ := json.Marshal(msg)
+		fmt.Fprintf(&sb, "Content-Length: %v\r\n\r\n%s", len(content), content)
+	}
+	return sb.String()
+}
+
+func positionRequest(id int, method string, line int, character int) map[string]any {
+	return map[string]any{"id": id, "method": method, "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+		"position":     map[string]any{"line": line, "character": character},
+		"context":      map[string]any{"includeDeclaration": true},
+	}}
+}
+
+func runServer(t *testing.T, requests ...map[string]any) (map[int]message, []message) {
+	requests = append([]map[string]any{
+		{"id": 1, "method": "initialize", "params": map[string]any{}},
+		{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": testURI, "version": 1, "text": testProgram},
+		}},
+	}, requests...)
+	requests = append(requests, map[string]any{"id": 99, "method": "shutdown"}, map[string]any{"method": "exit"})
+
+	out := &bytes.Buffer{}
+	server := NewServer(strings.NewReader(frame(requests...)), out, assembler.DefaultMemoryMap())
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := map[int]message{}
+	notifications := []message{}
+	reader := &Server{in: bufio.NewReader(out)}
+	for {
+		content, err := reader.readMessage()
+		if err != nil {
+			break
+		}
+		msg := message{}
+		if err := json.Unmarshal(content, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.ID != nil {
+			responses[*msg.ID] = msg
+		} else {
+			notifications = append(notifications, msg)
+		}
+	}
+	return responses, notifications
+}
+
+func TestServer_Diagnostics(t *testing.T) {
+	_, notifications := runServer(t)
+
+	if len(notifications) != 1 || notifications[0].Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("Expected a single publishDiagnostics notification, got: %+v", notifications)
+	}
+	params := PublishDiagnosticsParams{}
+	json.Unmarshal(notifications[0].Params, &params)
+
+	if len(params.Diagnostics) != 1 {
+		t.Fatalf("Expected 1 diagnostic, got: %+v", params.Diagnostics)
+	}
+	diagnostic := params.Diagnostics[0]
+	expectedRange := Range{Start: Position{Line: 10, Character: 0}, End: Position{Line: 10, Character: 3}}
+	if diagnostic.Range != expectedRange || !strings.Contains(diagnostic.Message, "unknown comp (Q)") {
+		t.Errorf("Unexpected diagnostic: %+v", diagnostic)
+	}
+}
+
+func TestServer_DefinitionAndReferences(t *testing.T) {
+	responses, _ := runServer(t,
+		positionRequest(2, "textDocument/definition", 8, 2),
+		positionRequest(3, "textDocument/references", 6, 1),
+	)
+
+	definition := []Location{}
+	json.Unmarshal(responses[2].Result, &definition)
+	expected := Range{Start: Position{Line: 5, Character: 1}, End: Position{Line: 5, Character: 5}}
+	if len(definition) != 1 || definition[0].Range != expected || definition[0].URI != testURI {
+		t.Errorf("Unexpected definition of LOOP: %+v", definition)
+	}
+
+	references := []Location{}
+	json.Unmarshal(responses[3].Result, &references)
+	lines := []int{}
+	for _, ref := range references {
+		lines = append(lines, ref.Range.Start.Line)
+	}
+	if fmt.Sprint(lines) != "[3 6]" {
+		t.Errorf("Unexpected references to i on lines: %v", lines)
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	responses, _ := runServer(t, positionRequest(2, "textDocument/hover", 3, 1))
+
+	hover := Hover{}
+	json.Unmarshal(responses[2].Result, &hover)
+	if !strings.Contains(hover.Contents.Value, "**i** (variable) = 16") ||
+		!strings.Contains(hover.Contents.Value, "ROM[2]: `0000000000010000`") {
+		t.Errorf("Unexpected hover: %+v", hover)
+	}
+}
+
+func TestServer_Completion(t *testing.T) {
+	responses, _ := runServer(t,
+		positionRequest(2, "textDocument/completion", 13, 2),
+		positionRequest(3, "textDocument/completion", 6, 1),
+	)
+
+	labels := func(id int) []string {
+		items := []CompletionItem{}
+		json.Unmarshal(responses[id].Result, &items)
+		result := []string{}
+		for _, item := range items {
+			result = append(result, item.Label)
+		}
+		return result
+	}
+
+	if jumps := labels(2); len(jumps) != 7 || jumps[0] != "JEQ" {
+		t.Errorf("Expected jump completions, got: %v", jumps)
+	}
+	if symbols := strings.Join(labels(3), ","); symbols != "END,LOOP,i" {
+		t.Errorf("Expected symbol completions, got: %v", symbols)
+	}
+}
+
+func TestServer_DocumentSymbols(t *testing.T) {
+	responses, _ := runServer(t, map[string]any{"id": 2, "method": "textDocument/documentSymbol", "params": map[string]any{
+		"textDocument": map[string]any{"uri": testURI},
+	}})
+
+	symbols := []DocumentSymbol{}
+	json.Unmarshal(responses[2].Result, &symbols)
+	if len(symbols) != 2 || symbols[0].Name != "LOOP" || symbols[0].Detail != "ROM[4]" || symbols[1].Name != "END" {
+		t.Errorf("Unexpected document symbols: %+v", symbols)
+	}
+}
+
+func TestServer_UnknownMethod(t *testing.T) {
+	responses, _ := runServer(t, map[string]any{"id": 2, "method": "textDocument/formatting"})
+
+	if responses[2].Error == nil || responses[2].Error.Code != codeMethodNotFound {
+		t.Errorf("Expected method not found error, got: %+v", responses[2])
+	}
+}
+
+func TestServer_MessageTooLarge(t *testing.T) {
+	out := &bytes.Buffer{}
+	server := NewServer(strings.NewReader("Content-Length: 99999999999\r\n\r\n{}"), out, assembler.DefaultMemoryMap())
+	if err := server.Serve(); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("Expected ErrMessageTooLarge, got %v", err)
+	}
+
+	content, err := (&Server{in: bufio.NewReader(out)}).readMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := message{}
+	if err := json.Unmarshal(content, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Code != codeInvalidRequest {
+		t.Errorf("Expected an invalid request error, got %s", content)
+	}
+}
+
+func TestServer_OutOfRangePositions(t *testing.T) {
+	responses, _ := runServer(t,
+		positionRequest(2, "textDocument/hover", 3, -1),
+		positionRequest(3, "textDocument/definition", 8, -1),
+		positionRequest(4, "textDocument/completion", 6, -1),
+		positionRequest(5, "textDocument/definition", 8, 100),
+		positionRequest(6, "textDocument/completion", 6, 100),
+		positionRequest(7, "textDocument/references", 100, 0),
+	)
+
+	for id := 2; id <= 7; id++ {
+		if responses[id].Error != nil {
+			t.Errorf("Request %v: unexpected error %+v", id, responses[id].Error)
+		}
+	}
+	definition := []Location{}
+	json.Unmarshal(responses[5].Result, &definition)
+	if len(definition) != 0 {
+		t.Errorf("Expected no definition past the end of the line, got: %+v", definition)
+	}
+}
+
+func TestDocument_UTF16Positions(t *testing.T) {
+	// "ü" is one UTF-16 unit and two bytes, "😀" two units and four bytes
+	doc := analyse(testURI, "(LOOP)\n// ü😀 LOOP\n@LOOP\n0;JMP", assembler.DefaultMemoryMap())
+
+	if info, exists := doc.symbolAt(Position{Line: 1, Character: 8}); !exists || info.Name != "LOOP" {
+		t.Errorf("Expected LOOP at character 8, got: %+v", info)
+	}
+	if _, exists := doc.symbolAt(Position{Line: 1, Character: 5}); exists {
+		t.Error("Expected no symbol within the emoji")
+	}
+	if pos := doc.position(1, 10); pos != (Position{Line: 1, Character: 7}) {
+		t.Errorf("Expected byte 10 to be character 7, got: %+v", pos)
+	}
+}
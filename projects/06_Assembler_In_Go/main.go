@@ -1,5 +1,219 @@
 package main
 
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/lsp"
+)
+
+type options struct {
+	xrefFormat string
+	cfgFormat  string
+	writeJSON  bool
+	sourceMap  bool
+	inputMap   string
+	summary    bool
+	quiet      bool // Discard the assembler's progress messages
+}
+
 func main() {
+	opts := options{}
+	flag.StringVar(&opts.xrefFormat, "xref", "", "also write a symbol cross-reference: text (Prog.xref) or json (Prog.xref.json)")
+	flag.StringVar(&opts.cfgFormat, "cfg", "", "also write the control-flow graph: dot (Prog.cfg.dot) or json (Prog.cfg.json)")
+	flag.BoolVar(&opts.writeJSON, "json", false, "also write the decoded program and symbol table as JSON (Prog.json)")
+	flag.BoolVar(&opts.sourceMap, "sourcemap", false, "also write a source map from ROM addresses to source lines (Prog.hack.map)")
+	flag.BoolVar(&opts.summary, "summary", false, "print a size summary: instructions, labels, variables and ROM usage")
+	flag.StringVar(&opts.inputMap, "insourcemap", "", "source map of the .asm input to chain through (default Prog.asm.map, if it exists)")
+	memoryMapFile := flag.String("memmap", "", "JSON memory map file adding predefined symbols and reserved regions")
+	lspMode := flag.Bool("lsp", false, "run as a language server, speaking JSON-RPC over stdin/stdout")
+	watchMode := flag.Bool("watch", false, "keep running, reassembling whenever an input file, the memory map or an input source map changes")
+	interval := flag.Duration("interval", 500*time.Millisecond, "how often to poll for changes in -watch mode")
+	command := flag.String("exec", "", "command to run (via the shell) after each successful reassembly in -watch mode")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] Prog.asm\n       %s -watch [flags] Prog.asm ...\n       %s -lsp [-memmap file]\n",
+			os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if (opts.xrefFormat != "" && opts.xrefFormat != "text" && opts.xrefFormat != "json") ||
+		(opts.cfgFormat != "" && opts.cfgFormat != "dot" && opts.cfgFormat != "json") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *watchMode {
+		if flag.NArg() == 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		opts.quiet = true
+		watch(flag.Args(), *memoryMapFile, opts, *interval, *command)
+	}
+
+	memoryMap := assembler.DefaultMemoryMap()
+	if *memoryMapFile != "" {
+		var err error
+		if memoryMap, err = assembler.LoadMemoryMap(*memoryMapFile); err != nil {
+			fmt.Fprintf(os.Stderr, "assembler: invalid memory map: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *lspMode {
+		if err := lsp.NewServer(os.Stdin, os.Stdout, memoryMap).Serve(); err != nil {
+			fmt.Fprintf(os.Stderr, "assembler: language server: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if _, err := assemble(flag.Arg(0), memoryMap, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "\nassembler: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Assembles a single file, writing the .hack and any requested extra outputs.
+// Returns the files the build depends on, even if assembling failed.
+func assemble(inputFile string, memoryMap *assembler.MemoryMap, opts options) ([]string, error) {
+	a := assembler.NewAssemblerWithMemoryMap(memoryMap)
+	if opts.quiet {
+		a.DebugOutput = io.Discard
+	}
+	dependencies := func() []string {
+		files := a.SourceFiles()
+		if opts.sourceMap {
+			// Prog.asm.map is watched even before it exists, so creating
+			// it is a change
+			inputMap := opts.inputMap
+			if inputMap == "" {
+				inputMap = inputFile + ".map"
+			}
+			files = append(files, inputMap)
+		}
+		return files
+	}
+	if err := a.Run(inputFile); err != nil {
+		return dependencies(), err
+	}
+	filename := strings.TrimSuffix(inputFile, ".asm")
+
+	if opts.xrefFormat != "" {
+		if err := writeCrossReference(a, filename, opts.xrefFormat); err != nil {
+			return dependencies(), fmt.Errorf("failed writing cross-reference: %v", err)
+		}
+	}
+
+	if opts.writeJSON {
+		if err := writeProgramJSON(a, filename); err != nil {
+			return dependencies(), fmt.Errorf("failed writing JSON program: %v", err)
+		}
+	}
+
+	if opts.sourceMap {
+		if err := writeSourceMap(a, inputFile, opts.inputMap); err != nil {
+			return dependencies(), fmt.Errorf("failed writing source map: %v", err)
+		}
+	}
+
+	if opts.cfgFormat != "" {
+		if err := writeControlFlowGraph(a, filename, opts.cfgFormat); err != nil {
+			return dependencies(), fmt.Errorf("failed writing control-flow graph: %v", err)
+		}
+	}
+
+	summary := a.Summary()
+	if opts.summary {
+		fmt.Printf("\n%v\n", summary)
+	}
+	if summary.NearLimit() {
+		fmt.Fprintf(os.Stderr, "warning: program uses %.2f%% of the ROM\n", summary.ROMUsage())
+	}
+	return dependencies(), nil
+}
+
+func writeCrossReference(a *assembler.Assembler, filename string, format string) error {
+	outputFilename := filename + ".xref"
+	if format == "json" {
+		outputFilename += ".json"
+	}
+
+	file, err := os.Create(outputFilename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "json" {
+		return assembler.WriteCrossReferenceJSON(file, a.CrossReference())
+	}
+	return assembler.WriteCrossReferenceText(file, a.CrossReference())
+}
+
+func writeControlFlowGraph(a *assembler.Assembler, filename string, format string) error {
+	cfg, err := a.ControlFlowGraph()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename + ".cfg." + format)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "json" {
+		return cfg.WriteJSON(file)
+	}
+	return cfg.WriteDOT(file)
+}
+
+func writeProgramJSON(a *assembler.Assembler, filename string) error {
+	file, err := os.Create(filename + ".json")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return a.WriteProgramJSON(file)
+}
+
+// Writes Prog.hack.map, chained through the input's own source map if it has one
+func writeSourceMap(a *assembler.Assembler, inputFile string, inputMapFile string) error {
+	hackFile := strings.TrimSuffix(inputFile, ".asm") + ".hack"
+	sourceMap := a.SourceMap(filepath.Base(hackFile))
+
+	if inputMapFile == "" {
+		if _, err := os.Stat(inputFile + ".map"); err == nil {
+			inputMapFile = inputFile + ".map"
+		}
+	}
+	if inputMapFile != "" {
+		inputMap, err := assembler.LoadSourceMap(inputMapFile)
+		if err != nil {
+			return err
+		}
+		sourceMap = sourceMap.Chain(inputMap)
+	}
+
+	file, err := os.Create(hackFile + ".map")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
+	return sourceMap.WriteJSON(file)
 }
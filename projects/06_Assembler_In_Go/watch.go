@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+// What's polled to detect a change, without relying on OS file notifications
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func statFiles(files []string) map[string]fileState {
+	states := map[string]fileState{}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			states[file] = fileState{}
+			continue
+		}
+		states[file] = fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+	}
+	return states
+}
+
+// Reports whether any of the files changed (or appeared/disappeared) since the states were taken
+func filesChanged(states map[string]fileState) bool {
+	files := []string{}
+	for file := range states {
+		files = append(files, file)
+	}
+	for file, state := range statFiles(files) {
+		if state != states[file] {
+			return true
+		}
+	}
+	return false
+}
+
+// An input file and the state of the files its build depends on (the
+// source and any input source map), when it was last assembled
+type watchedInput struct {
+	inputFile    string
+	dependencies map[string]fileState
+}
+
+type watcher struct {
+	inputs        []*watchedInput
+	memoryMapFile string
+	memoryMap     *assembler.MemoryMap
+	memoryMapDeps map[string]fileState
+	opts          options
+	command       string
+	// Assembles a single input, returning the files it read, assemble if nil
+	assembleFile func(inputFile string, memoryMap *assembler.MemoryMap, opts options) ([]string, error)
+}
+
+// Assembles the inputs, then polls every interval and reassembles only
+// the inputs whose files (or the memory map file) changed. Never returns.
+func watch(inputFiles []string, memoryMapFile string, opts options, interval time.Duration, command string) {
+	w := &watcher{memoryMapFile: memoryMapFile, opts: opts, command: command}
+	for _, inputFile := range inputFiles {
+		w.inputs = append(w.inputs, &watchedInput{inputFile: inputFile})
+	}
+
+	w.loadMemoryMap()
+	w.build(w.inputs)
+
+	for {
+		time.Sleep(interval)
+
+		if memoryMapFile != "" && filesChanged(w.memoryMapDeps) {
+			w.loadMemoryMap()
+			w.build(w.inputs)
+			continue
+		}
+
+		changed := []*watchedInput{}
+		for _, input := range w.inputs {
+			if filesChanged(input.dependencies) {
+				changed = append(changed, input)
+			}
+		}
+		if len(changed) > 0 {
+			w.build(changed)
+		}
+	}
+}
+
+// Reloads the memory map, keeping the previous one if the new one is invalid
+func (w *watcher) loadMemoryMap() {
+	if w.memoryMap == nil {
+		w.memoryMap = assembler.DefaultMemoryMap()
+	}
+	if w.memoryMapFile == "" {
+		return
+	}
+
+	w.memoryMapDeps = statFiles([]string{w.memoryMapFile})
+	memoryMap, err := assembler.LoadMemoryMap(w.memoryMapFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] invalid memory map, keeping the previous one: %v\n", timestamp(), err)
+		return
+	}
+	w.memoryMap = memoryMap
+}
+
+func (w *watcher) build(inputs []*watchedInput) {
+	failed := false
+	for _, input := range inputs {
+		if err := w.buildInput(input); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			failed = true
+		}
+	}
+
+	if w.command != "" && !failed {
+		w.runCommand()
+	}
+}
+
+// Assembles a single input, again straight away if any of its files were
+// saved while it was being assembled
+func (w *watcher) buildInput(input *watchedInput) error {
+	assembleFile := w.assembleFile
+	if assembleFile == nil {
+		assembleFile = assemble
+	}
+
+	for {
+		fmt.Printf("[%s] assembling %s\n", timestamp(), input.inputFile)
+		// Always watch the input itself, so a missing or unreadable file is retried
+		watched := []string{input.inputFile}
+		for file := range input.dependencies {
+			watched = append(watched, file)
+		}
+		before := statFiles(watched)
+
+		files, err := assembleFile(input.inputFile, w.memoryMap, w.opts)
+
+		// The files are stated before they're read, so a save while
+		// assembling shows up as a change and it's assembled again
+		after := statFiles(append(files, input.inputFile))
+		input.dependencies = after
+		changed := false
+		for file, state := range before {
+			if current, read := after[file]; read && current != state {
+				changed = true
+			}
+		}
+		if !changed {
+			return err
+		}
+	}
+}
+
+func (w *watcher) runCommand() {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, flag, w.command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] command failed: %v\n", timestamp(), err)
+	}
+}
+
+func timestamp() string {
+	return time.Now().Format("15:04:05")
+}
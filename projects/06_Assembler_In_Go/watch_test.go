@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+func TestFilesChanged(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Prog.asm")
+	missing := filepath.Join(dir, "Missing.asm")
+	os.WriteFile(file, []byte("@0\n"), 0644)
+
+	states := statFiles([]string{file, missing})
+	if filesChanged(states) {
+		t.Fatal("Nothing changed yet")
+	}
+
+	// Same size, so only the modification time differs
+	os.WriteFile(file, []byte("@1\n"), 0644)
+	os.Chtimes(file, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	if !filesChanged(states) {
+		t.Error("Expected a modified file to be detected")
+	}
+
+	states = statFiles([]string{file, missing})
+	os.WriteFile(missing, []byte("@0\n"), 0644)
+	if !filesChanged(states) {
+		t.Error("Expected a created file to be detected")
+	}
+}
+
+func TestWatcher_Build(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Prog.asm")
+	os.WriteFile(file, []byte("@0\nD=Q\n"), 0644)
+
+	w := &watcher{opts: options{quiet: true}, command: "touch " + filepath.Join(dir, "ran")}
+	w.loadMemoryMap()
+	input := &watchedInput{inputFile: file}
+
+	// The command only runs after a successful build
+	w.build([]*watchedInput{input})
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("Command should not run when assembling fails")
+	}
+	if _, watched := input.dependencies[file]; !watched {
+		t.Error("Input file should be watched after a failed build")
+	}
+
+	os.WriteFile(file, []byte("@0\nD=M\n"), 0644)
+	w.build([]*watchedInput{input})
+	if _, err := os.Stat(filepath.Join(dir, "Prog.hack")); err != nil {
+		t.Error("Expected Prog.hack to be written")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err != nil {
+		t.Error("Expected the command to run after a successful build")
+	}
+}
+
+func TestWatcher_ChangedWhileAssembling(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Prog.asm")
+	os.WriteFile(file, []byte("@0\n"), 0644)
+
+	// The first build is saved over half way through
+	builds := 0
+	w := &watcher{opts: options{quiet: true}}
+	w.assembleFile = func(inputFile string, memoryMap *assembler.MemoryMap, opts options) ([]string, error) {
+		builds++
+		files, err := assemble(inputFile, memoryMap, opts)
+		if builds == 1 {
+			os.WriteFile(file, []byte("@1\n"), 0644)
+			os.Chtimes(file, time.Now().Add(time.Second), time.Now().Add(time.Second))
+		}
+		return files, err
+	}
+	w.loadMemoryMap()
+	input := &watchedInput{inputFile: file}
+
+	w.build([]*watchedInput{input})
+	if builds != 2 {
+		t.Errorf("Expected the edit to be reassembled straight away, got %v builds", builds)
+	}
+	if filesChanged(input.dependencies) {
+		t.Error("Expected the dependencies to match the last build")
+	}
+	if hack, _ := os.ReadFile(filepath.Join(dir, "Prog.hack")); string(hack) != "0000000000000001\n" {
+		t.Errorf("Expected the edited program to be assembled, got %q", hack)
+	}
+}
+
+func TestWatcher_InputSourceMap(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Prog.asm")
+	os.WriteFile(file, []byte("@0\n"), 0644)
+
+	w := &watcher{opts: options{quiet: true, sourceMap: true}}
+	w.loadMemoryMap()
+	input := &watchedInput{inputFile: file}
+	w.build([]*watchedInput{input})
+
+	// The map the build would chain through is watched before it exists
+	if _, watched := input.dependencies[file+".map"]; !watched {
+		t.Fatalf("Expected Prog.asm.map to be watched, got %v", input.dependencies)
+	}
+	os.WriteFile(file+".map", []byte(`{"version": 1, "file": "Prog.asm", "mappings": []}`), 0644)
+	if !filesChanged(input.dependencies) {
+		t.Error("Expected a new input source map to be detected")
+	}
+}
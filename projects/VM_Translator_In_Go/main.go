@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/VM_Translator_In_Go/translator"
+)
+
+func main() {
+	opts := translator.Options{}
+	flag.StringVar(&opts.OutputFile, "o", "", "output file (default Prog.asm for Prog.vm, or Dir/Dir.asm for a directory)")
+	bootstrap := flag.Bool("bootstrap", false, "emit the bootstrap code (SP=256, call the entry function), the default if the entry function is defined")
+	noBootstrap := flag.Bool("no-bootstrap", false, "leave out the bootstrap code, the default if the entry function isn't defined")
+	opts.Pointers = translator.Pointers{}
+	for _, pointer := range []string{"SP", "LCL", "ARG", "THIS", "THAT"} {
+		pointer := pointer
+		usage := fmt.Sprintf("the %s the bootstrap code sets, if any", pointer)
+		if pointer == "SP" {
+			usage = fmt.Sprintf("the SP the bootstrap code sets (default %v)", translator.DefaultStackPointer)
+		}
+		flag.Func(strings.ToLower(pointer), usage, func(value string) error {
+			n, err := strconv.Atoi(value)
+			opts.Pointers[pointer] = n
+			return err
+		})
+	}
+	flag.IntVar(&opts.OptimizationLevel, "O", 0, fmt.Sprintf("optimization level, 0 to %v", translator.MaxOptimizationLevel))
+	flag.BoolVar(&opts.StripComments, "strip-comments", false, "leave the VM command comments out of the output")
+	flag.BoolVar(&opts.SourceMap, "sourcemap", false, "also write a source map from output lines to .vm lines (Prog.asm.map), which the assembler chains through")
+	flag.BoolVar(&opts.FastComparisons, "fast-compare", false, "shorter gt/lt code that is wrong when x-y overflows")
+	flag.BoolVar(&opts.OptimizeSize, "Os", false, "optimize for size, sharing one copy of the call, return and compare code")
+	flag.BoolVar(&opts.CacheTOS, "tos", false, "optimize for speed, keeping the top of the stack in the D register")
+	flag.BoolVar(&opts.VerifyStack, "verify-stack", false, "check every function keeps its stack balanced, and report its max depth")
+	flag.BoolVar(&opts.ExtendedArithmetic, "ext", false, "accept the mul, div, mod, shl and shr extension commands")
+	flag.BoolVar(&opts.Halt, "halt", false, "accept the halt command, which stops the program")
+	flag.BoolVar(&opts.EliminateDeadFunctions, "dce", false, "leave out functions that can't be reached from where the program starts")
+	flag.StringVar(&opts.EntryFunction, "entry", translator.DefaultEntryFunction, "the function the bootstrap code calls, and -dce keeps everything reachable from")
+	report := flag.Bool("report", false, "print the size of the generated code, which -Os, -dce and -verify-stack always do")
+	callGraphFormat := flag.String("callgraph", "", "also write the call graph of the source: dot (Prog.callgraph.dot) or json (Prog.callgraph.json)")
+	callGraphOnly := flag.Bool("callgraph-only", false, "only write the call graph, without translating")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] Prog.vm|Dir\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || (*bootstrap && *noBootstrap) ||
+		(*callGraphFormat != "" && *callGraphFormat != "dot" && *callGraphFormat != "json") ||
+		(*callGraphOnly && *callGraphFormat == "") ||
+		opts.OptimizationLevel < 0 || opts.OptimizationLevel > translator.MaxOptimizationLevel {
+		flag.Usage()
+		os.Exit(2)
+	}
+	input := flag.Arg(0)
+
+	// Like the course's test programs: a program with a Sys.init is a whole
+	// program, anything else is run by its own test script
+	opts.Bootstrap = *bootstrap
+	opts.AutoBootstrap = !*bootstrap && !*noBootstrap
+
+	if *callGraphFormat != "" {
+		if err := writeCallGraph(input, opts.OutputFile, *callGraphFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "vmtranslate: %v\n", err)
+			os.Exit(1)
+		}
+		if *callGraphOnly {
+			return
+		}
+	}
+
+	// The options that report what they did always print the summary
+	if *report || opts.OptimizeSize || opts.EliminateDeadFunctions || opts.VerifyStack {
+		opts.Report = os.Stdout
+	}
+	if err := translator.Translate([]string{input}, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "vmtranslate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Writes Prog.callgraph.dot or .json next to the output file, warning
+// about any recursion or undefined functions. The graph is of the parsed
+// source, before any pass like -dce has changed it.
+func writeCallGraph(input string, outputFile string, format string) error {
+	vmFiles, err := translator.FindVMFiles(input)
+	if err != nil {
+		return err
+	}
+	program, err := translator.ParseProgram(vmFiles)
+	if err != nil {
+		return err
+	}
+	graph := translator.BuildCallGraph(program)
+
+	if outputFile == "" {
+		if outputFile, err = translator.DefaultOutputFile(input); err != nil {
+			return err
+		}
+	}
+	file, err := os.Create(strings.TrimSuffix(outputFile, ".asm") + ".callgraph." + format)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		err = graph.WriteJSON(file)
+	} else {
+		err = graph.WriteDOT(file)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	for _, cycle := range graph.Cycles() {
+		fmt.Fprintf(os.Stderr, "note: recursion between %s\n", strings.Join(cycle, ", "))
+	}
+	for _, undefined := range graph.Undefined() {
+		fmt.Fprintf(os.Stderr, "warning: %s is called by %s but never defined\n", undefined.Name, strings.Join(undefined.CalledBy, ", "))
+	}
+	return file.Close()
+}
@@ -0,0 +1,322 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A function in the call graph, and the functions it calls
+type FunctionNode struct {
+	Name   string   `json:"name"`
+	Source Position `json:"source"`
+	// The number of call sites for each function it calls
+	Calls map[string]int `json:"calls"`
+}
+
+// Which functions call which, across the whole program
+type CallGraph struct {
+	Functions map[string]*FunctionNode
+	// Functions in the order they're defined
+	Order []string
+	// Calls from code outside of any function, like the project 7/8 test
+	// programs, which are always reachable
+	TopLevelCalls map[string]int
+	// Whether any module has commands before its first function
+	TopLevelCode bool
+}
+
+// A function that's called but never defined, and the functions calling it
+type UndefinedFunction struct {
+	Name     string   `json:"name"`
+	CalledBy []string `json:"calledBy"`
+}
+
+// The caller name used for calls from code outside of any function
+const TopLevelCaller = "(top level)"
+
+func BuildCallGraph(program *Program) *CallGraph {
+	graph := &CallGraph{Functions: map[string]*FunctionNode{}, TopLevelCalls: map[string]int{}}
+	for _, module := range program.Modules {
+		calls := graph.TopLevelCalls
+		inFunction := false
+		for _, cmd := range module.Commands {
+			switch cmd.Type {
+			case C_FUNCTION:
+				node := &FunctionNode{Name: cmd.Function, Source: cmd.Source, Calls: map[string]int{}}
+				graph.Functions[cmd.Function] = node
+				graph.Order = append(graph.Order, cmd.Function)
+				calls = node.Calls
+				inFunction = true
+			case C_CALL:
+				calls[cmd.Function]++
+			}
+			if !inFunction {
+				graph.TopLevelCode = true
+			}
+		}
+	}
+	return graph
+}
+
+// The names of the functions it calls, sorted
+func (n *FunctionNode) Callees() []string {
+	return sortedKeys(n.Calls)
+}
+
+// Every function reachable from the roots, including the roots themselves
+func (g *CallGraph) Reachable(roots ...string) map[string]bool {
+	reachable := map[string]bool{}
+	queue := append([]string{}, roots...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if reachable[name] {
+			continue
+		}
+		reachable[name] = true
+		if node, exists := g.Functions[name]; exists {
+			queue = append(queue, node.Callees()...)
+		}
+	}
+	return reachable
+}
+
+// Removes every function that can't be reached from where the program
+// starts. With the bootstrap that's the entry function, which has to be
+// defined. Without it, execution starts at the first command, so the roots
+// are the calls from code outside of any function, or the first function
+// (and the entry function, if defined) when there's no such code. Returns
+// the removed functions' names, in the order they were defined.
+func EliminateDeadFunctions(program *Program, entry string, bootstrap bool) ([]string, error) {
+	graph := BuildCallGraph(program)
+	_, entryExists := graph.Functions[entry]
+	roots := sortedKeys(graph.TopLevelCalls)
+	if bootstrap {
+		if !entryExists {
+			return nil, fmt.Errorf("entry function %s is not defined", entry)
+		}
+		roots = append(roots, entry)
+	} else if !graph.TopLevelCode && len(graph.Order) > 0 {
+		roots = append(roots, graph.Order[0])
+		if entryExists {
+			roots = append(roots, entry)
+		}
+	}
+	reachable := graph.Reachable(roots...)
+
+	removed := []string{}
+	for _, name := range graph.Order {
+		if !reachable[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	for _, module := range program.Modules {
+		commands := []Command{}
+		keep := true
+		for _, cmd := range module.Commands {
+			if cmd.Type == C_FUNCTION {
+				keep = reachable[cmd.Function]
+			}
+			if keep {
+				commands = append(commands, cmd)
+			}
+		}
+		module.Commands = commands
+	}
+	return removed, nil
+}
+
+// Groups of functions that call each other, directly or indirectly,
+// including functions that call themselves. Each cycle is in definition
+// order, found with Tarjan's strongly connected components algorithm.
+func (g *CallGraph) Cycles() [][]string {
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	components := [][]string{}
+
+	var connect func(name string)
+	connect = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, callee := range g.Functions[name].Callees() {
+			if _, defined := g.Functions[callee]; !defined {
+				continue
+			}
+			if _, visited := index[callee]; !visited {
+				connect(callee)
+				if lowLink[callee] < lowLink[name] {
+					lowLink[name] = lowLink[callee]
+				}
+			} else if onStack[callee] && index[callee] < lowLink[name] {
+				lowLink[name] = index[callee]
+			}
+		}
+
+		if lowLink[name] == index[name] {
+			component := []string{}
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == name {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, name := range g.Order {
+		if _, visited := index[name]; !visited {
+			connect(name)
+		}
+	}
+
+	position := map[string]int{}
+	for i, name := range g.Order {
+		position[name] = i
+	}
+	cycles := [][]string{}
+	for _, component := range components {
+		if len(component) == 1 && g.Functions[component[0]].Calls[component[0]] == 0 {
+			continue
+		}
+		sort.Slice(component, func(i, j int) bool { return position[component[i]] < position[component[j]] })
+		cycles = append(cycles, component)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return position[cycles[i][0]] < position[cycles[j][0]] })
+	return cycles
+}
+
+// Functions called but not defined anywhere in the program, sorted by name
+func (g *CallGraph) Undefined() []UndefinedFunction {
+	callers := map[string][]string{}
+	names := []string{}
+	addCalls := func(caller string, calls map[string]int) {
+		for _, callee := range sortedKeys(calls) {
+			if _, defined := g.Functions[callee]; defined {
+				continue
+			}
+			if _, seen := callers[callee]; !seen {
+				names = append(names, callee)
+			}
+			callers[callee] = append(callers[callee], caller)
+		}
+	}
+	addCalls(TopLevelCaller, g.TopLevelCalls)
+	for _, name := range g.Order {
+		addCalls(name, g.Functions[name].Calls)
+	}
+
+	sort.Strings(names)
+	undefined := []UndefinedFunction{}
+	for _, name := range names {
+		undefined = append(undefined, UndefinedFunction{Name: name, CalledBy: callers[name]})
+	}
+	return undefined
+}
+
+func (g *CallGraph) WriteJSON(w io.Writer) error {
+	functions := []*FunctionNode{}
+	for _, name := range g.Order {
+		functions = append(functions, g.Functions[name])
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Functions     []*FunctionNode     `json:"functions"`
+		TopLevelCalls map[string]int      `json:"topLevelCalls"`
+		Cycles        [][]string          `json:"cycles"`
+		Undefined     []UndefinedFunction `json:"undefined"`
+	}{functions, g.TopLevelCalls, g.Cycles(), g.Undefined()})
+}
+
+// Writes the graph in Graphviz format, with each file's functions grouped
+// together, recursive calls in red and undefined functions dashed
+func (g *CallGraph) WriteDOT(w io.Writer) error {
+	recursive := map[string]int{}
+	for i, cycle := range g.Cycles() {
+		for _, name := range cycle {
+			recursive[name] = i + 1
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph CallGraph {\n")
+	sb.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	files := []string{}
+	functionsByFile := map[string][]string{}
+	for _, name := range g.Order {
+		file := filepath.Base(g.Functions[name].Source.File)
+		if _, exists := functionsByFile[file]; !exists {
+			files = append(files, file)
+		}
+		functionsByFile[file] = append(functionsByFile[file], name)
+	}
+	for i, file := range files {
+		fmt.Fprintf(&sb, "  subgraph cluster_%v {\n", i)
+		fmt.Fprintf(&sb, "    label=%q;\n", file)
+		for _, name := range functionsByFile[file] {
+			if recursive[name] > 0 {
+				fmt.Fprintf(&sb, "    %q [color=red];\n", name)
+			} else {
+				fmt.Fprintf(&sb, "    %q;\n", name)
+			}
+		}
+		sb.WriteString("  }\n")
+	}
+
+	for _, undefined := range g.Undefined() {
+		fmt.Fprintf(&sb, "  %q [style=dashed, label=%q];\n", undefined.Name, undefined.Name+"\n(undefined)")
+	}
+	if len(g.TopLevelCalls) > 0 {
+		fmt.Fprintf(&sb, "  %q [shape=ellipse];\n", TopLevelCaller)
+	}
+
+	writeEdges := func(caller string, calls map[string]int) {
+		for _, callee := range sortedKeys(calls) {
+			attributes := fmt.Sprintf("label=%q", callLabel(calls[callee]))
+			if recursive[caller] > 0 && recursive[caller] == recursive[callee] {
+				attributes += ", color=red"
+			}
+			fmt.Fprintf(&sb, "  %q -> %q [%s];\n", caller, callee, attributes)
+		}
+	}
+	writeEdges(TopLevelCaller, g.TopLevelCalls)
+	for _, name := range g.Order {
+		writeEdges(name, g.Functions[name].Calls)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func callLabel(count int) string {
+	if count == 1 {
+		return "1 call"
+	}
+	return fmt.Sprintf("%v calls", count)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,731 @@
+package translator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+type CodeWriter struct {
+	outputFilename string
+	segmentMap     map[string]string
+	filename       string // The current module's name, e.g. Main for Main.vm
+	functionName   string // The function being translated, labels are scoped to it
+	callCount      int
+	opts           Options
+	// The generated Hack assembly, only written out on Close once it's been optimized
+	output []string
+	// The VM command each line of output was generated for, if any
+	sources []Position
+	// The command being written
+	source Position
+	// The shared routines jumped to in OptimizeSize mode
+	usedRoutines map[string]bool
+	// Whether the top of the stack is in D rather than RAM, with CacheTOS
+	cachedTOS bool
+	// Whether a halt command jumps to the halt loop
+	halts bool
+}
+
+func NewCodeWriter(outputFilename string, opts Options) (*CodeWriter, error) {
+	segmentMap := map[string]string{
+		"local":    "LCL",
+		"argument": "ARG",
+		"this":     "THIS",
+		"that":     "THAT",
+		"temp":     "R",
+	}
+
+	cw := &CodeWriter{outputFilename: outputFilename, callCount: 0, segmentMap: segmentMap, opts: opts, usedRoutines: map[string]bool{}}
+	return cw, nil
+}
+
+// Writes the code that sets up the stack and segment pointers, then calls
+// the entry function. The earlier project 7/8 test scripts have their own
+// init code instead.
+func (cw *CodeWriter) WriteBootstrap() {
+	cmds := []string{}
+	for _, name := range bootstrapPointers {
+		value, ok := cw.opts.Pointers[name]
+		if name == "SP" && !ok {
+			value, ok = DefaultStackPointer, true
+		}
+		if !ok {
+			continue
+		}
+		cmds = append(cmds, loadConstant(value)...)
+		cmds = append(cmds, "@"+name)
+		cmds = append(cmds, "M=D")
+	}
+	cw.appendASMCommands(cmds)
+
+	cw.WriteCall(cw.opts.entryFunction(), 0)
+}
+
+// Writes the generated code to the output file
+func (cw *CodeWriter) Close() error {
+	cw.spill()
+	file, err := os.OpenFile(cw.outputFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed when creating Hack Asm output file: %s", err)
+	}
+	writer := bufio.NewWriter(file)
+
+	output, sources := cw.outputWithSources()
+	sourceMap := &assembler.SourceMap{Version: assembler.SourceMapVersion, File: filepath.Base(cw.outputFilename), Mappings: []assembler.SourceMapEntry{}}
+	lineNumber := 0
+	for i, line := range output {
+		if cw.opts.StripComments && strings.HasPrefix(line, "//") {
+			continue
+		}
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			file.Close()
+			return err
+		}
+		lineNumber++
+		// VM commands are mapped to the start of their line
+		if sources[i].File != "" {
+			sourceMap.Mappings = append(sourceMap.Mappings, assembler.SourceMapEntry{
+				Generated: lineNumber, File: sources[i].File, Line: sources[i].Line, Column: 1})
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if cw.opts.SourceMap {
+		return writeSourceMap(cw.outputFilename+".map", sourceMap)
+	}
+	return nil
+}
+
+func writeSourceMap(filename string, sourceMap *assembler.SourceMap) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := sourceMap.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// The generated code, after any optimization
+func (cw *CodeWriter) Output() []string {
+	output, _ := cw.outputWithSources()
+	return output
+}
+
+func (cw *CodeWriter) outputWithSources() ([]string, []Position) {
+	if cw.opts.OptimizationLevel >= 1 {
+		return optimizeLines(cw.output, cw.sources)
+	}
+	return cw.output, cw.sources
+}
+
+// Informs the CodeWriter that translation of a new VM file has started,
+// static variables are named after the file
+func (cw *CodeWriter) SetFileName(filename string) {
+	cw.spill()
+	cw.filename = moduleName(filename)
+	cw.functionName = ""
+}
+
+// Labels are scoped to their function, or to the module for code outside
+// of any function, like the project 7/8 test programs
+func (cw *CodeWriter) scope() string {
+	if cw.functionName != "" {
+		return cw.functionName
+	}
+	if cw.filename != "" {
+		return cw.filename
+	}
+	// The bootstrap code, before any file
+	return "Bootstrap"
+}
+
+func (cw *CodeWriter) WriteCommand(cmd Command) {
+	cw.source = cmd.Source
+	defer func() { cw.source = Position{} }()
+
+	if cw.opts.CacheTOS && cw.writeCached(cmd) {
+		return
+	}
+
+	switch cmd.Type {
+	case C_PUSH, C_POP:
+		cw.WritePushPop(cmd.Text, cmd.Type, cmd.Segment, cmd.Index)
+	case C_LABEL:
+		cw.WriteLabel(cmd.Label)
+	case C_GOTO:
+		cw.WriteGoto(cmd.Label)
+	case C_IF:
+		cw.WriteIf(cmd.Label)
+	case C_FUNCTION:
+		cw.WriteFunction(cmd.Function, cmd.NVars)
+	case C_CALL:
+		cw.WriteCall(cmd.Function, cmd.NArgs)
+	case C_RETURN:
+		cw.WriteReturn()
+	case C_HALT:
+		cw.WriteHalt()
+	case C_MOVE:
+		cw.writeMove(cmd)
+	case C_ADD_CONSTANT:
+		cw.writeAddConstant(cmd)
+	default:
+		cw.WriteArithmetic(cmd.Op)
+	}
+}
+
+func (cw *CodeWriter) WriteArithmetic(command string) {
+	cmds := []string{}
+
+	if command == "eq" || command == "lt" || command == "gt" {
+		cw.writeComparison(command)
+	} else if extensionOps[command] {
+		cw.writeExtension(command)
+	} else if command == "neg" {
+		// Neg
+		cmds = append(cmds, "// "+command)
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "M=-M")
+		cw.appendASMCommands(cmds)
+	} else if command == "not" {
+		// Neg
+		cmds = append(cmds, "// "+command)
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "M=!M")
+		cw.appendASMCommands(cmds)
+	} else {
+		var op string
+		switch command {
+		case "add":
+			op = "+"
+		case "sub":
+			op = "-"
+		case "or":
+			op = "|"
+		case "and":
+			op = "&"
+		}
+		// Add
+		cmds = append(cmds, "// "+command)
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "AM=M-1")
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "AM=M-1")
+		if op == "-" {
+			cmds = append(cmds, "M=M-D")
+		} else {
+			// The commutative ops only exist as D+M, D&M and D|M in the Hack comp table
+			cmds = append(cmds, "M=D"+op+"M")
+		}
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "M=M+1")
+		cw.appendASMCommands(cmds)
+	}
+}
+
+func (cw *CodeWriter) writeComparison(command string) {
+	if cw.opts.OptimizeSize {
+		cw.writeSharedComparison(command)
+		return
+	}
+
+	// eq, gt, lt
+	op := "J" + strings.ToUpper(command)
+	id := cw.callCount
+	cw.callCount += 1
+	labelPrefix := fmt.Sprintf("%s%v", generatedPrefix, strings.ToUpper(command))
+
+	cmds := []string{}
+	cmds = append(cmds, "// "+command)
+	if command == "eq" || cw.opts.FastComparisons {
+		// x-y, which for gt/lt can overflow and give the wrong sign
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "AM=M-1")
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "D=M-D")
+	} else {
+		cmds = append(cmds, cw.compareSigns(labelPrefix, fmt.Sprintf(".%v", id))...)
+	}
+	cmds = append(cmds, fmt.Sprintf("@%s_TRUE.%v", labelPrefix, id))
+	cmds = append(cmds, "D;"+op)
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, fmt.Sprintf("@%s_END.%v", labelPrefix, id))
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, fmt.Sprintf("(%s_TRUE.%v)", labelPrefix, id))
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=-1")
+	cmds = append(cmds, fmt.Sprintf("(%s_END.%v)", labelPrefix, id))
+
+	cw.appendASMCommands(cmds)
+}
+
+// Pops y and leaves D with the sign of x-y, without overflowing. If x and
+// y have different signs, x-y could overflow but x's sign is the answer,
+// otherwise the subtraction is safe.
+func (cw *CodeWriter) compareSigns(labelPrefix string, labelSuffix string) []string {
+	xNegative := labelPrefix + "_XNEG" + labelSuffix
+	subtract := labelPrefix + "_SUB" + labelSuffix
+	compare := labelPrefix + "_CMP" + labelSuffix
+
+	cmds := []string{}
+	// Save y in R13 and load x
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "AM=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+xNegative)
+	cmds = append(cmds, "D;JLT")
+	// x >= 0, so if y < 0 then x > y
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+subtract)
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "D=1")
+	cmds = append(cmds, "@"+compare)
+	cmds = append(cmds, "0;JMP")
+	// x < 0, so if y >= 0 then x < y
+	cmds = append(cmds, "("+xNegative+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+subtract)
+	cmds = append(cmds, "D;JLT")
+	cmds = append(cmds, "D=-1")
+	cmds = append(cmds, "@"+compare)
+	cmds = append(cmds, "0;JMP")
+	// Same signs, x-y can't overflow
+	cmds = append(cmds, "("+subtract+")")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=D-M")
+	cmds = append(cmds, "("+compare+")")
+	return cmds
+}
+
+func (cw *CodeWriter) writePop(command string, segment string, index int) {
+	cmds := []string{}
+	cmds = append(cmds, "// "+command)
+
+	if symbol, direct := cw.directSymbol(segment, index); direct {
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "AM=M-1")
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, "@"+symbol)
+		cmds = append(cmds, "M=D")
+	} else {
+		cmds = append(cmds, cw.addressToR13(segment, index)...)
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "AM=M-1")
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, "@R13")
+		cmds = append(cmds, "A=M")
+		cmds = append(cmds, "M=D")
+	}
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) writePush(command string, segment string, index int) {
+	cmds := []string{}
+	cmds = append(cmds, "// "+command)
+	cmds = append(cmds, cw.loadSegment(segment, index)...)
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	cw.appendASMCommands(cmds)
+}
+
+// The symbol for an entry in the temp, pointer and static segments, which
+// unlike the others are at a fixed address
+func (cw *CodeWriter) directSymbol(segment string, index int) (string, bool) {
+	switch segment {
+	case "temp":
+		return fmt.Sprintf("%s%v", cw.segmentMap[segment], (5 + index)), true
+	case "pointer":
+		if index == 0 {
+			return "THIS", true
+		}
+		return "THAT", true
+	case "static":
+		return staticSymbol(cw.filename, index), true
+	}
+	return "", false
+}
+
+// Sets D to segment[index]
+func (cw *CodeWriter) loadSegment(segment string, index int) []string {
+	cmds := []string{}
+	if segment == "constant" {
+		cmds = append(cmds, loadConstant(index)...)
+	} else if symbol, direct := cw.directSymbol(segment, index); direct {
+		cmds = append(cmds, "@"+symbol)
+		cmds = append(cmds, "D=M")
+	} else {
+		cmds = append(cmds, fmt.Sprintf("@%s", cw.segmentMap[segment]))
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, fmt.Sprintf("@%v", index))
+		cmds = append(cmds, "A=D+A")
+		cmds = append(cmds, "D=M")
+	}
+	return cmds
+}
+
+// Sets D to a constant. The VM optimizer can produce negative constants,
+// which don't fit in an A-instruction, but their complement does.
+func loadConstant(value int) []string {
+	if value < 0 {
+		return []string{fmt.Sprintf("@%v", ^value), "D=!A"}
+	}
+	return []string{fmt.Sprintf("@%v", value), "D=A"}
+}
+
+// Stores the address of segment[index] in R13, for the segments based at
+// a pointer
+func (cw *CodeWriter) addressToR13(segment string, index int) []string {
+	cmds := []string{}
+	cmds = append(cmds, fmt.Sprintf("@%s", cw.segmentMap[segment]))
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, fmt.Sprintf("@%v", index))
+	cmds = append(cmds, "D=D+A")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+	return cmds
+}
+
+// A push immediately popped somewhere else, from the VM optimizer
+func (cw *CodeWriter) writeMove(cmd Command) {
+	cmds := []string{}
+	cmds = append(cmds, "// "+cmd.String())
+
+	if symbol, direct := cw.directSymbol(cmd.DestSegment, cmd.DestIndex); direct {
+		cmds = append(cmds, cw.loadSegment(cmd.Segment, cmd.Index)...)
+		cmds = append(cmds, "@"+symbol)
+		cmds = append(cmds, "M=D")
+	} else {
+		cmds = append(cmds, cw.addressToR13(cmd.DestSegment, cmd.DestIndex)...)
+		cmds = append(cmds, cw.loadSegment(cmd.Segment, cmd.Index)...)
+		cmds = append(cmds, "@R13")
+		cmds = append(cmds, "A=M")
+		cmds = append(cmds, "M=D")
+	}
+	cw.appendASMCommands(cmds)
+}
+
+// push constant k; add, from the VM optimizer
+func (cw *CodeWriter) writeAddConstant(cmd Command) {
+	cmds := []string{}
+	cmds = append(cmds, "// "+cmd.String())
+
+	switch cmd.Value {
+	case 1:
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "M=M+1")
+	case -1:
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "M=M-1")
+	default:
+		cmds = append(cmds, loadConstant(cmd.Value)...)
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "M=D+M")
+	}
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) WritePushPop(command string, commandType CommandType, segment string, index int) {
+	if commandType == C_PUSH {
+		cw.writePush(command, segment, index)
+	} else {
+		cw.writePop(command, segment, index)
+	}
+}
+
+func (cw *CodeWriter) appendASMCommands(asmCommands []string) {
+	cw.output = append(cw.output, asmCommands...)
+	for range asmCommands {
+		cw.sources = append(cw.sources, cw.source)
+	}
+}
+
+// The program's single halt loop, written once at the end of its code
+func (cw *CodeWriter) WriteInfiniteLoop() {
+	cw.spill()
+	cmds := []string{}
+	cmds = append(cmds, "// Infinite Loop")
+	cmds = append(cmds, "("+haltLabel+")")
+	cmds = append(cmds, "@"+haltLabel)
+	cmds = append(cmds, "0;JMP")
+	cw.appendASMCommands(cmds)
+}
+
+// Stops the program by jumping to the halt loop
+func (cw *CodeWriter) WriteHalt() {
+	cw.spill()
+	cw.halts = true
+	cmds := []string{}
+	cmds = append(cmds, "// halt")
+	cmds = append(cmds, "@"+haltLabel)
+	cmds = append(cmds, "0;JMP")
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) WriteLabel(label string) {
+	label = labelSymbol(cw.scope(), label)
+
+	cmds := []string{}
+	cmds = append(cmds, "// label "+label)
+	cmds = append(cmds, fmt.Sprintf("(%v)", label))
+	cw.appendASMCommands(cmds)
+
+}
+
+func (cw *CodeWriter) WriteGoto(label string) {
+	label = labelSymbol(cw.scope(), label)
+
+	cmds := []string{}
+	cmds = append(cmds, "// goto "+label)
+	cmds = append(cmds, fmt.Sprintf("@%v", label))
+	cmds = append(cmds, "0;JMP") // Unconditional jump
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) WriteIf(label string) {
+	label = labelSymbol(cw.scope(), label)
+
+	cmds := []string{}
+	cmds = append(cmds, "// if-goto "+label)
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "AM=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, fmt.Sprintf("@%v", label))
+	cmds = append(cmds, "D;JNE") // Jump if D != 0
+	cw.appendASMCommands(cmds)
+
+}
+
+func (cw *CodeWriter) WriteFunction(functionName string, nVars int) {
+	cw.functionName = functionName
+
+	cmds := []string{}
+	cmds = append(cmds, fmt.Sprintf("// function %s %v", functionName, nVars))
+	// function entry label
+	cmds = append(cmds, fmt.Sprintf("(%s)", functionName))
+	// Skip initialization if nArgs == 0
+	cmds = append(cmds, fmt.Sprintf("@%v", nVars))
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, fmt.Sprintf("@%s%s$INIT_END", generatedPrefix, functionName))
+	cmds = append(cmds, "D;JLE")
+	// Set temp counter var = nArgs
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+	// Initialization loop
+	cmds = append(cmds, fmt.Sprintf("(%s%s$INIT_LOOP)", generatedPrefix, functionName))
+	// Push zero onto stack
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=0")
+	// Increment SP
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Decremept temp args counter
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "MD=M-1")
+	// Continue loop if temp args counter > 0
+	cmds = append(cmds, fmt.Sprintf("@%s%s$INIT_LOOP", generatedPrefix, functionName))
+	cmds = append(cmds, "D;JGT")
+	// We've pushed 0 onto stack for each arg, now end
+	cmds = append(cmds, fmt.Sprintf("(%s%s$INIT_END)", generatedPrefix, functionName))
+
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) WriteCall(functionName string, nVars int) {
+	if cw.opts.OptimizeSize {
+		cw.writeSharedCall(functionName, nVars)
+		return
+	}
+
+	cmds := []string{}
+	cmds = append(cmds, fmt.Sprintf("// call %s %v", functionName, nVars))
+	// Push return address onto stack
+	id := cw.callCount
+	cw.callCount += 1
+	returnAddress := fmt.Sprintf("%s%s$ret.%v", generatedPrefix, cw.scope(), id)
+	cmds = append(cmds, fmt.Sprintf("@%s", returnAddress))
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Push LCL address onto stack
+	cmds = append(cmds, "@LCL")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Push ARG address onto stack
+	cmds = append(cmds, "@ARG")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Push THIS address onto stack
+	cmds = append(cmds, "@THIS")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Push THAT address onto stack
+	cmds = append(cmds, "@THAT")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Call the function
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@5")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, fmt.Sprintf("@%v", nVars))
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "@ARG")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@LCL")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, fmt.Sprintf("@%s", functionName))
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, fmt.Sprintf("(%s)", returnAddress))
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) WriteReturn() {
+	// Saved Caller Frame Addresses:
+	// LCL-5 = Return Address
+	// LCL-4 = LCL Address
+	// LCL-3 = ARG Address
+	// LCL-2 = THIS Address
+	// LCL-1 = THAT Address
+
+	if cw.opts.OptimizeSize {
+		cw.writeSharedReturn()
+		return
+	}
+
+	cmds := []string{}
+	cmds = append(cmds, "// return")
+	cmds = append(cmds, returnCommands()...)
+	cw.appendASMCommands(cmds)
+}
+
+// Restores the caller's frame and jumps back to it, using R13 and R14
+func returnCommands() []string {
+	cmds := []string{}
+
+	// Save the value of LCL in R13
+	cmds = append(cmds, "@LCL")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+
+	// Store the return address (the value of LCL-5) in R14
+	cmds = append(cmds, "@5")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "A=D")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D")
+
+	// Pop the return value of the top of stack, store in ARG
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "AM=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@ARG")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+
+	// Set the SP to the address immediately after ARG
+	cmds = append(cmds, "@ARG")
+	cmds = append(cmds, "D=M+1")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=D")
+	// Set the THAT value to be the value of LCL - 1
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M-1")
+	cmds = append(cmds, "A=D")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@THAT")
+	cmds = append(cmds, "M=D")
+	// Set the THIS value to be the value of LCL - 2
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@2")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "A=D")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@THIS")
+	cmds = append(cmds, "M=D")
+	// Set the ARG value to be the value of LCL - 3
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@3")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "A=D")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@ARG")
+	cmds = append(cmds, "M=D")
+	// Set the LCL value to be the value of LCL - 4
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@4")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "A=D")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@LCL")
+	cmds = append(cmds, "M=D")
+	// Jump to the return address
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "0;JMP")
+	return cmds
+}
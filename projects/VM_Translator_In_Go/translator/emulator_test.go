@@ -0,0 +1,632 @@
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+// A minimal Hack CPU, enough to run the course's .tst scripts for the VM
+// test programs and check the resulting RAM against their .cmp files
+type hackComputer struct {
+	rom     []uint16
+	symbols map[string]int
+	ram     [32768]int16
+	a       int16
+	d       int16
+	pc      uint16
+}
+
+func newHackComputer(t *testing.T, asmFile string) *hackComputer {
+	file, err := os.Open(asmFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	lines, err := assembler.ParseSourceLines(asmFile, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := assembler.NewAssembler()
+	a.DebugOutput = io.Discard
+	words, err := a.Assemble(lines)
+	if err != nil {
+		t.Fatalf("Failed assembling %s: %v", asmFile, err)
+	}
+
+	computer := &hackComputer{symbols: a.SymbolMap}
+	for _, word := range words {
+		value, _ := strconv.ParseUint(word, 2, 16)
+		computer.rom = append(computer.rom, uint16(value))
+	}
+	return computer
+}
+
+func (c *hackComputer) step() {
+	// Like the CPU emulator, ROM beyond the program is all zeroes, i.e. @0
+	var instr uint16
+	if int(c.pc) < len(c.rom) {
+		instr = c.rom[c.pc]
+	}
+
+	if instr&0x8000 == 0 {
+		c.a = int16(instr)
+		c.pc++
+		return
+	}
+
+	y := c.a
+	if instr&0x1000 != 0 {
+		y = c.ram[uint16(c.a)&0x7FFF]
+	}
+	out := alu(c.d, y, instr>>6&0x3F)
+
+	address := uint16(c.a) & 0x7FFF
+	if instr&0x08 != 0 {
+		c.ram[address] = out
+	}
+	if instr&0x10 != 0 {
+		c.d = out
+	}
+	oldA := c.a
+	if instr&0x20 != 0 {
+		c.a = out
+	}
+
+	jump := (instr&0x04 != 0 && out < 0) || (instr&0x02 != 0 && out == 0) || (instr&0x01 != 0 && out > 0)
+	if jump {
+		c.pc = uint16(oldA)
+	} else {
+		c.pc++
+	}
+}
+
+// The Hack ALU, controlled by the zx nx zy ny f no bits
+func alu(x int16, y int16, control uint16) int16 {
+	if control&0x20 != 0 {
+		x = 0
+	}
+	if control&0x10 != 0 {
+		x = ^x
+	}
+	if control&0x08 != 0 {
+		y = 0
+	}
+	if control&0x04 != 0 {
+		y = ^y
+	}
+	out := x & y
+	if control&0x02 != 0 {
+		out = x + y
+	}
+	if control&0x01 != 0 {
+		out = ^out
+	}
+	return out
+}
+
+var (
+	tstSetPattern    = regexp.MustCompile(`set RAM\[(\d+)\] (-?\d+)`)
+	tstRepeatPattern = regexp.MustCompile(`repeat (\d+)`)
+)
+
+// Runs asmFile as directed by the .tst script (which sets RAM and runs a
+// number of cycles, times cycleFactor, before any output) and compares the
+// RAM to the .cmp file
+func runTestScript(t *testing.T, tstFile string, asmFile string, cycleFactor int) {
+	script, err := os.ReadFile(tstFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	computer := newHackComputer(t, asmFile)
+
+	for _, match := range tstSetPattern.FindAllStringSubmatch(string(script), -1) {
+		address, _ := strconv.Atoi(match[1])
+		value, _ := strconv.Atoi(match[2])
+		computer.ram[address] = int16(value)
+	}
+
+	cycles := 0
+	if match := tstRepeatPattern.FindStringSubmatch(string(script)); match != nil {
+		cycles, _ = strconv.Atoi(match[1])
+	}
+	for i := 0; i < cycles*cycleFactor; i++ {
+		computer.step()
+	}
+
+	expected := readCmpFile(t, strings.TrimSuffix(tstFile, ".tst")+".cmp")
+	for address, value := range expected {
+		if computer.ram[address] != value {
+			t.Errorf("%s: RAM[%v] = %v, expected %v", filepath.Base(asmFile), address, computer.ram[address], value)
+		}
+	}
+}
+
+// A .cmp file is alternating header and value rows, e.g.
+// |  RAM[0]  | RAM[256] |
+// |     257  |      15  |
+func readCmpFile(t *testing.T, cmpFile string) map[int]int16 {
+	contents, err := os.ReadFile(cmpFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]int16{}
+	rows := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	for i := 0; i+1 < len(rows); i += 2 {
+		headers := strings.Split(strings.Trim(strings.TrimSpace(rows[i]), "|"), "|")
+		values := strings.Split(strings.Trim(strings.TrimSpace(rows[i+1]), "|"), "|")
+
+		for j, header := range headers {
+			header = strings.TrimSpace(header)
+			address, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header, "RAM["), "]"))
+			if err != nil {
+				t.Fatalf("%s: unexpected column %s", cmpFile, header)
+			}
+			value, _ := strconv.Atoi(strings.TrimSpace(values[j]))
+			expected[address] = int16(value)
+		}
+	}
+	return expected
+}
+
+// Every test program, as its input and whether it needs the bootstrap code
+var testPrograms = []struct {
+	input     string
+	bootstrap bool
+}{
+	{"BasicTest.vm", false},
+	{"StackTest.vm", false},
+	{"SimpleAdd.vm", false},
+	{"PointerTest.vm", false},
+	{"StaticTest.vm", false},
+	{"BasicLoop.vm", false},
+	{"FibonacciSeries.vm", false},
+	{"SimpleFunction.vm", false},
+	{"FibonacciElement", true},
+	{"StaticsTest", true},
+}
+
+// The .tst script for a test program, e.g. testfiles/StaticsTest/StaticsTest.tst
+func testScript(input string) string {
+	if strings.HasSuffix(input, ".vm") {
+		return "testfiles/" + strings.TrimSuffix(input, ".vm") + ".tst"
+	}
+	return "testfiles/" + input + "/" + input + ".tst"
+}
+
+// The code generation options every test program is checked with. The
+// shared routines are smaller but slower, so need more cycles than the
+// test scripts allow for the inlined code.
+var testConfigurations = map[string]struct {
+	opts        Options
+	cycleFactor int
+}{
+	"default":   {Options{}, 1},
+	"O1":        {Options{OptimizationLevel: 1, StripComments: true}, 1},
+	"O2":        {Options{OptimizationLevel: 2}, 1},
+	"size-O2":   {Options{OptimizeSize: true, OptimizationLevel: 2}, 2},
+	"tos":       {Options{CacheTOS: true}, 1},
+	"tos-fast":  {Options{CacheTOS: true, FastComparisons: true}, 1},
+	"tos-O2":    {Options{CacheTOS: true, OptimizationLevel: 2}, 1},
+	"tos-size":  {Options{CacheTOS: true, OptimizeSize: true}, 2},
+	"size":      {Options{OptimizeSize: true}, 2},
+	"size-fast": {Options{OptimizeSize: true, FastComparisons: true}, 2},
+	"size-O1":   {Options{OptimizeSize: true, OptimizationLevel: 1}, 2},
+}
+
+func TestTranslator_CmpResults(t *testing.T) {
+	for name, config := range testConfigurations {
+		for _, program := range testPrograms {
+			t.Run(name+"/"+program.input, func(t *testing.T) {
+				opts := config.opts
+				opts.Bootstrap = program.bootstrap
+				opts.OutputFile = filepath.Join(t.TempDir(), "Out.asm")
+				if err := Translate([]string{"testfiles/" + program.input}, opts); err != nil {
+					t.Fatal(err)
+				}
+				runTestScript(t, testScript(program.input), opts.OutputFile, config.cycleFactor)
+			})
+		}
+	}
+}
+
+// Translates and runs VM code outside of any function, with the stack at
+// 256, for long enough to reach the final infinite loop
+func runVM(t *testing.T, source []string, opts Options, cycles int) *hackComputer {
+	dir := t.TempDir()
+	vmFile := filepath.Join(dir, "Prog.vm")
+	if err := os.WriteFile(vmFile, []byte(strings.Join(source, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts.OutputFile = filepath.Join(dir, "Prog.asm")
+	if err := Translate([]string{vmFile}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	computer := newHackComputer(t, opts.OutputFile)
+	computer.ram[0] = 256
+	for i := 0; i < cycles; i++ {
+		computer.step()
+	}
+	return computer
+}
+
+// The VM code to push any 16-bit value, as constants are only 0-32767
+func pushValue(value int16) []string {
+	switch {
+	case value == -32768:
+		return []string{"push constant 32767", "neg", "push constant 1", "sub"}
+	case value < 0:
+		return []string{fmt.Sprintf("push constant %v", -value), "neg"}
+	}
+	return []string{fmt.Sprintf("push constant %v", value)}
+}
+
+func TestTranslator_ComparisonBoundaries(t *testing.T) {
+	values := []int16{-32768, -32767, -20000, -2, -1, 0, 1, 2, 20000, 32766, 32767}
+	comparisons := map[string]func(x, y int16) bool{
+		"eq": func(x, y int16) bool { return x == y },
+		"gt": func(x, y int16) bool { return x > y },
+		"lt": func(x, y int16) bool { return x < y },
+	}
+
+	for op, compare := range comparisons {
+		// Every comparison of one value against all the others, in a single program
+		for _, x := range values {
+			source := []string{}
+			for _, y := range values {
+				source = append(source, pushValue(x)...)
+				source = append(source, pushValue(y)...)
+				source = append(source, op)
+			}
+
+			configurations := []Options{{}, {FastComparisons: true}, {OptimizeSize: true}, {OptimizeSize: true, FastComparisons: true}, {OptimizationLevel: 2},
+				{CacheTOS: true}, {CacheTOS: true, FastComparisons: true}, {CacheTOS: true, OptimizeSize: true}}
+			for _, opts := range configurations {
+				fast := opts.FastComparisons
+				computer := runVM(t, source, opts, 10000)
+				if computer.ram[0] != int16(256+len(values)) {
+					t.Fatalf("%v %s (%+v): expected SP=%v, got %v", x, op, opts, 256+len(values), computer.ram[0])
+				}
+
+				for i, y := range values {
+					expected := compare(x, y)
+					actual := computer.ram[256+i] == -1
+					if computer.ram[256+i] != 0 && !actual {
+						t.Errorf("%v %s %v: expected true (-1) or false (0), got %v", x, op, y, computer.ram[256+i])
+					}
+
+					// The fast version is only correct when x-y doesn't overflow
+					overflows := int(x)-int(y) != int(x-y)
+					if actual != expected && !(fast && overflows && op != "eq") {
+						t.Errorf("%v %s %v (%+v): expected %v, got %v", x, op, y, opts, expected, actual)
+					}
+				}
+			}
+		}
+	}
+
+	// The example from the bug report, -20000 < 20000, is wrong in the fast version
+	source := append(pushValue(-20000), append(pushValue(20000), "lt")...)
+	if runVM(t, source, Options{FastComparisons: true}, 1000).ram[256] != 0 {
+		t.Error("Expected the fast lt to overflow for -20000 < 20000")
+	}
+	if runVM(t, source, Options{}, 1000).ram[256] != -1 {
+		t.Error("Expected -20000 < 20000")
+	}
+}
+
+func TestTranslator_AddConstantBoundaries(t *testing.T) {
+	// -O 2 folds these into add constant -32768, which can't be negated
+	// into a single A-instruction
+	for _, x := range []int16{-32768, -1, 0, 1, 32767} {
+		source := append(pushValue(x), "pop static 0",
+			"push static 0", "push constant 32767", "push constant 1", "add", "add")
+		for _, name := range []string{"O2", "tos-O2"} {
+			computer := runVM(t, source, testConfigurations[name].opts, 1000)
+			if expected := x + -32768; computer.ram[256] != expected {
+				t.Errorf("%s: %v + -32768: expected %v, got %v", name, x, expected, computer.ram[256])
+			}
+		}
+	}
+}
+
+func TestTranslator_CacheTOSCycles(t *testing.T) {
+	// Sums 1-100 into static 0
+	source := []string{
+		"push constant 0", "pop static 0",
+		"push constant 100", "pop static 1",
+		"label LOOP",
+		"push static 0", "push static 1", "add", "pop static 0",
+		"push static 1", "push constant 1", "sub", "pop static 1",
+		"push static 1",
+		"if-goto LOOP",
+	}
+
+	cycles := map[bool]int{}
+	for _, cacheTOS := range []bool{false, true} {
+		computer := runVM(t, source, Options{CacheTOS: cacheTOS}, 0)
+		end := computer.symbols[haltLabel]
+		for int(computer.pc) != end && cycles[cacheTOS] < 100000 {
+			computer.step()
+			cycles[cacheTOS]++
+		}
+		if computer.ram[16] != 5050 || computer.ram[0] != 256 {
+			t.Errorf("CacheTOS=%v: expected static 0 = 5050 and SP=256, got %v and %v", cacheTOS, computer.ram[16], computer.ram[0])
+		}
+	}
+
+	if cycles[true] >= cycles[false] {
+		t.Errorf("Expected caching the top of the stack to be faster, took %v cycles rather than %v", cycles[true], cycles[false])
+	}
+	t.Logf("%v cycles, %v with the top of the stack cached", cycles[false], cycles[true])
+}
+
+func TestTranslator_EliminateDeadFunctions(t *testing.T) {
+	for _, input := range []string{"FibonacciElement", "StaticsTest"} {
+		report := &bytes.Buffer{}
+		opts := Options{Bootstrap: true, EliminateDeadFunctions: true, Report: report, OutputFile: filepath.Join(t.TempDir(), "Out.asm")}
+		if err := Translate([]string{"testfiles/" + input}, opts); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(report.String(), "Removed no unreachable functions") {
+			t.Errorf("%s: expected every function to be reachable, got %s", input, report)
+		}
+		runTestScript(t, testScript(input), opts.OutputFile, 1)
+	}
+
+	// FibonacciElement with some extra functions nothing calls
+	dir := t.TempDir()
+	for _, name := range []string{"Main.vm", "Sys.vm"} {
+		contents, err := os.ReadFile(filepath.Join("testfiles/FibonacciElement", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unused := "function Unused.a 0\ncall Unused.b 0\nreturn\nfunction Unused.b 2\npush local 1\nreturn\n"
+	if err := os.WriteFile(filepath.Join(dir, "Unused.vm"), []byte(unused), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &bytes.Buffer{}
+	opts := Options{Bootstrap: true, EliminateDeadFunctions: true, Report: report, OutputFile: filepath.Join(dir, "Out.asm")}
+	if err := Translate([]string{dir}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "Removed 2 unreachable functions: Unused.a, Unused.b") {
+		t.Errorf("Expected Unused.a and Unused.b to be removed, got %s", report)
+	}
+	runTestScript(t, testScript("FibonacciElement"), opts.OutputFile, 1)
+}
+
+func TestTranslator_Bootstrap(t *testing.T) {
+	// A Sys.init turns the bootstrap on, and its absence leaves it off
+	opts := Options{AutoBootstrap: true, OutputFile: filepath.Join(t.TempDir(), "Out.asm")}
+	if err := Translate([]string{"testfiles/FibonacciElement"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	runTestScript(t, testScript("FibonacciElement"), opts.OutputFile, 1)
+
+	opts.OutputFile = filepath.Join(t.TempDir(), "Out.asm")
+	if err := Translate([]string{"testfiles/SimpleAdd.vm"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	runTestScript(t, testScript("SimpleAdd.vm"), opts.OutputFile, 1)
+
+	// The pointers and entry function are configurable
+	source := []string{
+		"function Main.main 0",
+		"push pointer 0",
+		"pop static 0",
+		"push pointer 1",
+		"pop static 1",
+		"label HALT",
+		"goto HALT",
+	}
+	opts = Options{
+		AutoBootstrap: true,
+		EntryFunction: "Main.main",
+		Pointers:      Pointers{"SP": 300, "LCL": -1, "ARG": -2, "THIS": 3000, "THAT": -4},
+	}
+	computer := runVM(t, source, opts, 200)
+	if computer.ram[computer.symbols["Prog.0"]] != 3000 || computer.ram[computer.symbols["Prog.1"]] != -4 {
+		t.Errorf("Expected THIS=3000 and THAT=-4, got %v and %v",
+			computer.ram[computer.symbols["Prog.0"]], computer.ram[computer.symbols["Prog.1"]])
+	}
+	// The call's frame starts at the initial SP, with the caller's LCL and ARG saved in it
+	if computer.ram[2] != 300 || computer.ram[301] != -1 || computer.ram[302] != -2 {
+		t.Errorf("Expected ARG=300 and a saved LCL=-1, ARG=-2, got %v, %v and %v",
+			computer.ram[2], computer.ram[301], computer.ram[302])
+	}
+
+	// A pointer set to 0 is still written, and one not set isn't
+	opts = Options{Bootstrap: true, Pointers: Pointers{"THIS": 0}, OutputFile: filepath.Join(t.TempDir(), "Out.asm")}
+	if err := Translate([]string{"testfiles/FibonacciElement"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(opts.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup, _, _ := strings.Cut(string(output), "// call Sys.init 0")
+	if setup != "@256\nD=A\n@SP\nM=D\n@0\nD=A\n@THIS\nM=D\n" {
+		t.Errorf("Expected the bootstrap to set SP to 256 and THIS to 0, got:\n%s", setup)
+	}
+
+	// Asking for the bootstrap without an entry function is an error
+	opts = Options{Bootstrap: true, OutputFile: filepath.Join(t.TempDir(), "Out.asm")}
+	err = Translate([]string{"testfiles/SimpleAdd.vm"}, opts)
+	if err == nil || !strings.Contains(err.Error(), "bootstrap calls Sys.init, which is not defined") {
+		t.Errorf("Expected a missing Sys.init error, got %v", err)
+	}
+
+	// And so is a pointer the bootstrap code can't load
+	opts = Options{Bootstrap: true, Pointers: Pointers{"SP": 40000}, OutputFile: filepath.Join(t.TempDir(), "Out.asm")}
+	err = Translate([]string{"testfiles/FibonacciElement"}, opts)
+	if err == nil || err.Error() != "bootstrap SP 40000 is out of range, it must be -32768 to 32767" {
+		t.Errorf("Expected an out of range SP error, got %v", err)
+	}
+	opts = Options{Bootstrap: true, Pointers: Pointers{"TEMP": 5}, OutputFile: filepath.Join(t.TempDir(), "Out.asm")}
+	err = Translate([]string{"testfiles/FibonacciElement"}, opts)
+	if err == nil || !strings.Contains(err.Error(), "unknown bootstrap pointer") {
+		t.Errorf("Expected an unknown pointer error, got %v", err)
+	}
+}
+
+func TestTranslator_Halt(t *testing.T) {
+	// Without the bootstrap, every file's code runs in turn into a single
+	// halt loop. A program with functions after its code halts explicitly
+	// rather than running into them.
+	for _, files := range []map[string]string{
+		{
+			"A.vm": "push constant 7\npop static 0\n",
+			"B.vm": "push constant 3\npop static 0\n",
+		},
+		{
+			"A.vm": "push constant 7\ncall C.f 1\npop static 0\n",
+			"B.vm": "push constant 3\npop static 0\nhalt\n",
+			"C.vm": "function C.f 0\npush argument 0\npush constant 3\nadd\nreturn\n",
+		},
+	} {
+		dir := t.TempDir()
+		for name, contents := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		outputFile := filepath.Join(dir, "Out.asm")
+		if err := Translate([]string{dir}, Options{OutputFile: outputFile, Halt: true}); err != nil {
+			t.Fatal(err)
+		}
+		asm, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count := strings.Count(string(asm), "("+haltLabel+")"); count != 1 {
+			t.Errorf("Expected a single halt loop, got %v", count)
+		}
+		computer := newHackComputer(t, outputFile)
+		computer.ram[0] = 256
+		for i := 0; i < 200; i++ {
+			computer.step()
+		}
+		expected := 7
+		if len(files) == 3 {
+			expected = 10
+		}
+		a, b := computer.ram[computer.symbols["A.0"]], computer.ram[computer.symbols["B.0"]]
+		halted := int(computer.pc) == computer.symbols[haltLabel] || int(computer.pc) == computer.symbols[haltLabel]+1
+		if int(a) != expected || b != 3 || !halted {
+			t.Errorf("Expected A.0=%v, B.0=3 and to halt, got %v, %v at %v", expected, a, b, computer.pc)
+		}
+	}
+
+	// A halt command stops the program, leaving the stack in RAM
+	source := []string{
+		"function Sys.init 0",
+		"push constant 5",
+		"pop static 0",
+		"push constant 9",
+		"halt",
+		"push constant 6",
+		"pop static 0",
+		"return",
+	}
+	for _, opts := range []Options{{AutoBootstrap: true, Halt: true}, {AutoBootstrap: true, Halt: true, CacheTOS: true}, {AutoBootstrap: true, Halt: true, OptimizeSize: true}} {
+		computer := runVM(t, source, opts, 300)
+		sp := computer.ram[0]
+		if computer.ram[computer.symbols["Prog.0"]] != 5 || computer.ram[sp-1] != 9 {
+			t.Errorf("%+v: expected Prog.0=5 and 9 on the stack, got %v and %v", opts, computer.ram[computer.symbols["Prog.0"]], computer.ram[sp-1])
+		}
+	}
+
+	// Like the extension commands, it isn't part of standard programs
+	vmFile := filepath.Join(t.TempDir(), "Prog.vm")
+	if err := os.WriteFile(vmFile, []byte("push constant 1\nhalt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := Translate([]string{vmFile}, Options{})
+	if err == nil || err.Error() != vmFile+":2: halt: halt is an extension command, which isn't enabled" {
+		t.Errorf("Expected halt to be rejected, got %v", err)
+	}
+}
+
+func TestTranslator_ExtendedArithmetic(t *testing.T) {
+	values := []int16{-32768, -32767, -20000, -7, -2, -1, 0, 1, 2, 7, 15, 16, 20000, 32767}
+	shift := func(x, y int16, right bool) int16 {
+		if y < 0 {
+			return x
+		}
+		if y > 16 {
+			y = 16
+		}
+		result := int32(x)
+		for i := int16(0); i < y; i++ {
+			if right {
+				result >>= 1
+			} else {
+				result <<= 1
+			}
+		}
+		return int16(result)
+	}
+	ops := map[string]func(x, y int16) int16{
+		"mul": func(x, y int16) int16 { return int16(int32(x) * int32(y)) },
+		"div": func(x, y int16) int16 { return int16(int32(x) / int32(y)) },
+		"mod": func(x, y int16) int16 { return int16(int32(x) % int32(y)) },
+		"shl": func(x, y int16) int16 { return shift(x, y, false) },
+		"shr": func(x, y int16) int16 { return shift(x, y, true) },
+	}
+
+	for op, evaluate := range ops {
+		// Every value against all the others, in a single program
+		for _, x := range values {
+			source := []string{}
+			for _, y := range values {
+				source = append(source, pushValue(x)...)
+				source = append(source, pushValue(y)...)
+				source = append(source, op)
+			}
+
+			configurations := []Options{{ExtendedArithmetic: true}, {ExtendedArithmetic: true, CacheTOS: true},
+				{ExtendedArithmetic: true, OptimizationLevel: 2}}
+			for _, opts := range configurations {
+				computer := runVM(t, source, opts, 20000)
+				if computer.ram[0] != int16(256+len(values)) {
+					t.Fatalf("%v %s (%+v): expected SP=%v, got %v", x, op, opts, 256+len(values), computer.ram[0])
+				}
+				for i, y := range values {
+					if y == 0 && (op == "div" || op == "mod") {
+						continue
+					}
+					if expected := evaluate(x, y); computer.ram[256+i] != expected {
+						t.Errorf("%v %s %v (%+v): expected %v, got %v", x, op, y, opts, expected, computer.ram[256+i])
+					}
+				}
+			}
+		}
+	}
+
+	// Standard programs can't use them by accident
+	vmFile := filepath.Join(t.TempDir(), "Prog.vm")
+	if err := os.WriteFile(vmFile, []byte("push constant 6\npush constant 7\nmul\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := Translate([]string{vmFile}, Options{})
+	if err == nil || err.Error() != vmFile+":3: mul: mul is an extension command, which isn't enabled" {
+		t.Errorf("Expected mul to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,452 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The arithmetic commands beyond the standard nine, only accepted with
+// Options.ExtendedArithmetic. Each pops y and x and pushes:
+//
+//	mul: x*y, keeping the low 16 bits
+//	div: x/y, rounded towards 0 like Math.divide
+//	mod: x-(x/y)*y, so with the sign of x
+//	shl: x shifted left y bits, 0 once y is 16 or more
+//	shr: x shifted right y bits, copying the sign bit in
+//
+// A negative shift doesn't shift, and dividing by 0 gives a meaningless
+// result as there's no way to report the error.
+var extensionOps = map[string]bool{"mul": true, "div": true, "mod": true, "shl": true, "shr": true}
+
+// Rejects the extension commands, and halt, unless they've been asked for,
+// so a standard program can't come to rely on them by accident
+func checkExtensions(program *Program, opts Options) error {
+	errs := []error{}
+	for _, module := range program.Modules {
+		for _, cmd := range module.Commands {
+			name := ""
+			if cmd.Type == C_ARITHMETIC && extensionOps[cmd.Op] && !opts.ExtendedArithmetic {
+				name = cmd.Op
+			} else if cmd.Type == C_HALT && !opts.Halt {
+				name = "halt"
+			}
+			if name != "" {
+				errs = append(errs, &SourceError{Source: cmd.Source, Text: cmd.String(),
+					Message: fmt.Sprintf("%s is an extension command, which isn't enabled", name)})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// The extension commands always jump into a shared routine, emitted once
+// at the end of the program, passing the return address in D. The routine
+// keeps it in the free slot above y while it works.
+func (cw *CodeWriter) writeExtension(command string) {
+	routine := generatedPrefix + strings.ToUpper(command)
+	cw.usedRoutines[routine] = true
+	id := cw.callCount
+	cw.callCount += 1
+	returnAddress := fmt.Sprintf("%s_RET.%v", routine, id)
+
+	cmds := []string{}
+	cmds = append(cmds, "// "+command)
+	cmds = append(cmds, "@"+returnAddress)
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@"+routine)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, fmt.Sprintf("(%s)", returnAddress))
+	cw.appendASMCommands(cmds)
+}
+
+// Writes the routines used by any extension command, after the others
+func (cw *CodeWriter) writeExtensionRoutines() {
+	for _, op := range []string{"mul", "div", "mod", "shl", "shr"} {
+		routine := generatedPrefix + strings.ToUpper(op)
+		if !cw.usedRoutines[routine] {
+			continue
+		}
+		cmds := []string{}
+		cmds = append(cmds, fmt.Sprintf("// Shared %s routine", op))
+		cmds = append(cmds, "("+routine+")")
+		cmds = append(cmds, extensionEntry()...)
+		switch op {
+		case "mul":
+			cmds = append(cmds, mulCommands(routine)...)
+		case "div", "mod":
+			cmds = append(cmds, divideCommands(routine, op == "mod")...)
+		case "shl":
+			cmds = append(cmds, shlCommands(routine)...)
+		case "shr":
+			cmds = append(cmds, shrCommands(routine)...)
+		}
+		cmds = append(cmds, extensionExit()...)
+		cw.appendASMCommands(cmds)
+	}
+}
+
+// Saves the return address above y, pops y into R14 and copies x into R13,
+// leaving x's slot for the result
+func extensionEntry() []string {
+	cmds := []string{}
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "AM=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+	return cmds
+}
+
+// Returns to the address saved by extensionEntry
+func extensionExit() []string {
+	return []string{"@SP", "A=M+1", "A=M", "0;JMP"}
+}
+
+// Shift and add, adding x for each of y's bits from the bottom up, and
+// stopping once there are no more of them. R15 holds y's current bit.
+func mulCommands(routine string) []string {
+	loop := routine + "_LOOP"
+	next := routine + "_NEXT"
+	end := routine + "_END"
+
+	cmds := []string{}
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=1")
+	cmds = append(cmds, "("+loop+")")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+end)
+	cmds = append(cmds, "D;JEQ")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=D&M")
+	cmds = append(cmds, "@"+next)
+	cmds = append(cmds, "D;JEQ")
+	// Clear the bit from y and add x, shifted to match, to the result
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=M-D")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "("+next+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@"+loop)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+end+")")
+	return cmds
+}
+
+// Long division of |x| by |y|, one bit at a time. R13 starts as |x| and
+// each step shifts its top bit into the remainder in R15 and the next
+// quotient bit into its bottom, so it ends up as the quotient. The step
+// count is kept in y's old slot and the result's sign in the slot after
+// the return address.
+func divideCommands(routine string, remainder bool) []string {
+	start := routine + "_START"
+	xPositive := routine + "_XPOS"
+	yPositive := routine + "_YPOS"
+	small := routine + "_SMALL"
+	loop := routine + "_LOOP"
+	shift := routine + "_SHIFT"
+	subtract := routine + "_SUBTRACT"
+	next := routine + "_NEXT"
+	done := routine + "_DONE"
+	positive := routine + "_POSITIVE"
+	sign := []string{"@SP", "D=M", "@2", "A=D+A"}
+
+	cmds := []string{}
+	// Make both positive, noting whether the result is negative: for div
+	// when their signs differ, for mod when x is negative
+	cmds = append(cmds, sign...)
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+xPositive)
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=-M")
+	cmds = append(cmds, sign...)
+	cmds = append(cmds, "M=!M")
+	cmds = append(cmds, "("+xPositive+")")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+yPositive)
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=-M")
+	if !remainder {
+		cmds = append(cmds, sign...)
+		cmds = append(cmds, "M=!M")
+	}
+	cmds = append(cmds, "("+yPositive+")")
+
+	// |y| = 32768 is still negative, and only goes into x = -32768
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+start)
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+small)
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=1")
+	cmds = append(cmds, "@"+done)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+small+")")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@"+done)
+	cmds = append(cmds, "0;JMP")
+
+	cmds = append(cmds, "("+start+")")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@16")
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "("+loop+")")
+	// Shift the top bit of R13 into the remainder
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+shift)
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=M+1")
+	cmds = append(cmds, "("+shift+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	// The remainder is below 2|y|, so if its top bit is set it's already
+	// over |y|, and otherwise they can be compared with a subtraction
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+subtract)
+	cmds = append(cmds, "D;JLT")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=D-M")
+	cmds = append(cmds, "@"+next)
+	cmds = append(cmds, "D;JLT")
+	cmds = append(cmds, "("+subtract+")")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=M-D")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=M+1")
+	cmds = append(cmds, "("+next+")")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=M-1")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+loop)
+	cmds = append(cmds, "D;JGT")
+
+	// Replace x with the quotient or remainder, with its sign
+	result := "@R13"
+	if remainder {
+		result = "@R15"
+	}
+	cmds = append(cmds, "("+done+")")
+	cmds = append(cmds, sign...)
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+positive)
+	cmds = append(cmds, "D;JEQ")
+	cmds = append(cmds, result)
+	cmds = append(cmds, "M=-M")
+	cmds = append(cmds, "("+positive+")")
+	cmds = append(cmds, result)
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=D")
+	return cmds
+}
+
+// Doubles x y times, or clears it straight away for 16 or more
+func shlCommands(routine string) []string {
+	loop := routine + "_LOOP"
+	end := routine + "_END"
+
+	cmds := []string{}
+	// Checking for a negative y first, as y-16 could overflow
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+end)
+	cmds = append(cmds, "D;JLE")
+	cmds = append(cmds, "@16")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "@"+loop)
+	cmds = append(cmds, "D;JLT")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@"+end)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+loop+")")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+end)
+	cmds = append(cmds, "D;JLE")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D-1")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@"+loop)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+end+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=D")
+	return cmds
+}
+
+// The Hack ALU can't shift right, so this copies x's bits from bit y
+// upwards, in R15, into the result from bit 0 upwards, in R14. Once R15
+// has run off the top, R14 is the first bit that wasn't filled, and for a
+// negative x every bit from there up is set.
+func shrCommands(routine string) []string {
+	source := routine + "_SOURCE"
+	sourceLoop := routine + "_SOURCE_LOOP"
+	loop := routine + "_LOOP"
+	next := routine + "_NEXT"
+	copying := routine + "_COPY"
+	end := routine + "_END"
+
+	cmds := []string{}
+	// Shifting 16 or more leaves just the sign, checking for a negative y
+	// first as y-16 could overflow
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+source)
+	cmds = append(cmds, "D;JLE")
+	cmds = append(cmds, "@16")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "@"+source)
+	cmds = append(cmds, "D;JLT")
+	cmds = append(cmds, "@16")
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D")
+	// R15 = bit y
+	cmds = append(cmds, "("+source+")")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=1")
+	cmds = append(cmds, "("+sourceLoop+")")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+copying)
+	cmds = append(cmds, "D;JLE")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D-1")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@"+sourceLoop)
+	cmds = append(cmds, "0;JMP")
+
+	cmds = append(cmds, "("+copying+")")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=1")
+	cmds = append(cmds, "("+loop+")")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+end)
+	cmds = append(cmds, "D;JEQ")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=D&M")
+	cmds = append(cmds, "@"+next)
+	cmds = append(cmds, "D;JEQ")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "("+next+")")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "M=D+M")
+	cmds = append(cmds, "@"+loop)
+	cmds = append(cmds, "0;JMP")
+
+	cmds = append(cmds, "("+end+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+routine+"_DONE")
+	cmds = append(cmds, "D;JGE")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=-M")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=D|M")
+	cmds = append(cmds, "("+routine+"_DONE)")
+	return cmds
+}
+
+// The value of an extension command, for constant folding
+func foldExtension(op string, x int16, y int16) (int16, bool) {
+	switch op {
+	case "mul":
+		return x * y, true
+	case "div":
+		if y == 0 {
+			return 0, false
+		}
+		return x / y, true
+	case "mod":
+		if y == 0 {
+			return 0, false
+		}
+		return x % y, true
+	case "shl":
+		if y < 0 {
+			return x, true
+		} else if y >= 16 {
+			return 0, true
+		}
+		return x << y, true
+	case "shr":
+		if y < 0 {
+			return x, true
+		} else if y >= 16 {
+			return x >> 15, true
+		}
+		return x >> y, true
+	}
+	return 0, false
+}
@@ -0,0 +1,83 @@
+package translator
+
+import "strings"
+
+// An instruction sequence and what it can be replaced with. Patterns are
+// matched against consecutive instructions, so never span a label.
+type peephole struct {
+	match       []string
+	replacement []string
+}
+
+var peepholes = []peephole{
+	// A push immediately followed by a pop: SP++ then SP--, A=SP
+	{
+		match:       []string{"@SP", "M=M+1", "@SP", "AM=M-1"},
+		replacement: []string{"@SP", "A=M"},
+	},
+}
+
+// Applies the peephole patterns to the generated assembly, skipping over
+// comment lines but otherwise leaving them where they were
+func optimizeASM(lines []string) []string {
+	output, _ := optimizeLines(lines, make([]Position, len(lines)))
+	return output
+}
+
+// optimizeASM, keeping each line's VM source alongside it. A replacement
+// takes the source of the first instruction it replaces.
+func optimizeLines(lines []string, sources []Position) ([]string, []Position) {
+	output := []string{}
+	outputSources := []Position{}
+	// Indexes into output of the instructions, i.e. everything but comments
+	instructions := []int{}
+
+	for i, line := range lines {
+		output = append(output, line)
+		outputSources = append(outputSources, sources[i])
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		instructions = append(instructions, len(output)-1)
+
+		for _, p := range peepholes {
+			if len(instructions) < len(p.match) {
+				continue
+			}
+			window := instructions[len(instructions)-len(p.match):]
+			if !matchesPeephole(output, window, p.match) {
+				continue
+			}
+
+			// Drop the matched instructions and any comments between
+			// them, keeping the comments so they still precede the code
+			comments := []string{}
+			commentSources := []Position{}
+			for j, line := range output[window[0]:] {
+				if strings.HasPrefix(line, "//") {
+					comments = append(comments, line)
+					commentSources = append(commentSources, outputSources[window[0]+j])
+				}
+			}
+			source := outputSources[window[0]]
+			output = append(output[:window[0]], comments...)
+			outputSources = append(outputSources[:window[0]], commentSources...)
+			instructions = instructions[:len(instructions)-len(p.match)]
+			for _, replacement := range p.replacement {
+				output = append(output, replacement)
+				outputSources = append(outputSources, source)
+				instructions = append(instructions, len(output)-1)
+			}
+		}
+	}
+	return output, outputSources
+}
+
+func matchesPeephole(output []string, window []int, match []string) bool {
+	for i, index := range window {
+		if output[index] != match[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package translator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A problem with a line of a .vm file, e.g.
+// Main.vm:12: pop constant 5: can't pop to the constant segment
+type SourceError struct {
+	Source  Position
+	Text    string
+	Message string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", e.Source, e.Text, e.Message)
+}
+
+type Parser struct {
+	file           *os.File
+	scanner        bufio.Scanner
+	currentCommand string
+	fields         []string
+	moreLines      bool
+	filename       string
+	path           string
+	lineNumber     int
+}
+
+func NewParser(vmFilename string) (*Parser, error) {
+	if filepath.Ext(vmFilename) != ".vm" {
+		return nil, fmt.Errorf("input file: (%s) must have .vm extension", vmFilename)
+	}
+	file, err := os.Open(vmFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parser{file: file, moreLines: true, scanner: *bufio.NewScanner(file), filename: filepath.Base(vmFilename), path: vmFilename}
+	return p, nil
+}
+
+// The name of the file being parsed, without its directory
+func (p *Parser) FileName() string {
+	return p.filename
+}
+
+func (p *Parser) Close() error {
+	return p.file.Close()
+}
+
+func (p *Parser) HasMoreLines() bool {
+	return p.moreLines
+}
+
+// Reads the next line into the current command. It may have no command
+// if the line was blank or only a comment.
+func (p *Parser) Advance() error {
+	p.moreLines = p.scanner.Scan()
+	p.lineNumber++
+	line := p.scanner.Text()
+	// The command's text keeps any trailing comment, which is copied into
+	// the generated code
+	p.currentCommand = strings.TrimSpace(line)
+	if i := strings.Index(line, "//"); i >= 0 {
+		line = line[:i]
+	}
+	p.fields = strings.Fields(line)
+
+	return p.scanner.Err()
+}
+
+// Whether the current line has a command, rather than being blank or a comment
+func (p *Parser) HasCommand() bool {
+	return len(p.fields) > 0
+}
+
+// The current command, only valid once Advance has read a non-empty line.
+// Returns a *SourceError if it isn't a valid VM command.
+func (p *Parser) Command() (Command, error) {
+	cmdType := p.CommandType()
+	if cmdType == 0 {
+		return Command{}, p.errorf("unknown command %s", p.fields[0])
+	}
+
+	expected := expectedFields[cmdType]
+	if cmdType == C_ARITHMETIC {
+		expected = p.fields[0]
+	}
+	if len(p.fields) != len(strings.Fields(expected)) {
+		return Command{}, p.errorf("expected \"%s\"", expected)
+	}
+
+	cmd := Command{Type: cmdType, Text: p.currentCommand, Source: Position{File: p.path, Line: p.lineNumber}}
+	n := 0
+	switch cmd.Type {
+	case C_PUSH, C_POP, C_FUNCTION, C_CALL:
+		arg2, err := strconv.Atoi(p.fields[2])
+		if err != nil || arg2 < 0 {
+			return Command{}, p.errorf("%s is not a non-negative integer, expected \"%s\"", p.fields[2], expected)
+		}
+		n = arg2
+	}
+
+	switch cmd.Type {
+	case C_ARITHMETIC:
+		cmd.Op = p.Arg1()
+	case C_PUSH, C_POP:
+		cmd.Segment, cmd.Index = p.Arg1(), n
+		if err := p.checkSegment(cmd); err != nil {
+			return Command{}, err
+		}
+	case C_LABEL, C_GOTO, C_IF:
+		cmd.Label = p.Arg1()
+	case C_FUNCTION:
+		cmd.Function, cmd.NVars = p.Arg1(), n
+	case C_CALL:
+		cmd.Function, cmd.NArgs = p.Arg1(), n
+	}
+
+	switch cmd.Type {
+	case C_LABEL, C_GOTO, C_IF, C_FUNCTION, C_CALL:
+		if !isSymbol(p.Arg1()) {
+			return Command{}, p.errorf("%s is not a valid symbol, expected letters, digits, _, . and : not starting with a digit", p.Arg1())
+		}
+	}
+	return cmd, nil
+}
+
+// The form of each type of command, for error messages
+var expectedFields = map[CommandType]string{
+	C_PUSH:     "push segment index",
+	C_POP:      "pop segment index",
+	C_LABEL:    "label symbol",
+	C_GOTO:     "goto symbol",
+	C_IF:       "if-goto symbol",
+	C_FUNCTION: "function name nVars",
+	C_CALL:     "call name nArgs",
+	C_RETURN:   "return",
+	C_HALT:     "halt",
+}
+
+// The number of entries in the fixed size segments
+var segmentSizes = map[string]int{
+	"constant": 32768, // A-instructions are 15 bits
+	"pointer":  2,     // THIS and THAT
+	"temp":     8,     // R5-R12
+	"static":   240,   // RAM[16-255]
+}
+
+func (p *Parser) checkSegment(cmd Command) error {
+	switch cmd.Segment {
+	case "local", "argument", "this", "that", "constant", "static", "pointer", "temp":
+	default:
+		return p.errorf("unknown segment %s, expected one of local, argument, this, that, constant, static, pointer or temp", cmd.Segment)
+	}
+
+	if cmd.Type == C_POP && cmd.Segment == "constant" {
+		return p.errorf("can't pop to the constant segment")
+	}
+	if size, fixed := segmentSizes[cmd.Segment]; fixed && cmd.Index >= size {
+		return p.errorf("%s index %v is out of range, expected 0-%v", cmd.Segment, cmd.Index, size-1)
+	}
+	return nil
+}
+
+// A label or function name: letters, digits, "_", "." and ":", not
+// starting with a digit
+func isSymbol(name string) bool {
+	for i, c := range name {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !isDigit && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+		if i == 0 && isDigit {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func (p *Parser) errorf(format string, args ...any) *SourceError {
+	return &SourceError{Source: Position{File: p.path, Line: p.lineNumber}, Text: strings.Join(p.fields, " "), Message: fmt.Sprintf(format, args...)}
+}
+
+// The type of the current command, or 0 if it isn't a known command
+func (p *Parser) CommandType() CommandType {
+	var cmdType CommandType
+
+	switch p.fields[0] {
+	case "push":
+		cmdType = C_PUSH
+	case "pop":
+		cmdType = C_POP
+	case "add", "sub", "neg", "eq", "gt", "lt", "and", "or", "not":
+		cmdType = C_ARITHMETIC
+	case "mul", "div", "mod", "shl", "shr":
+		// Extensions, see checkExtensions
+		cmdType = C_ARITHMETIC
+	case "label":
+		cmdType = C_LABEL
+	case "goto":
+		cmdType = C_GOTO
+	case "if-goto":
+		cmdType = C_IF
+	case "function":
+		cmdType = C_FUNCTION
+	case "call":
+		cmdType = C_CALL
+	case "return":
+		cmdType = C_RETURN
+	case "halt":
+		cmdType = C_HALT
+	}
+
+	return cmdType
+}
+
+func (p *Parser) Arg1() string {
+	// add -> arg1 = 'add'
+	// lt -> arg1 = 'lt'
+
+	if p.CommandType() == C_ARITHMETIC || p.CommandType() == C_RETURN || p.CommandType() == C_HALT {
+		return p.fields[0]
+	}
+
+	// push local 2 -> arg1 = 'local'
+	// label LOOP -> arg1 = "LOOP"
+	return p.fields[1]
+}
+
+func (p *Parser) Arg2() int {
+	// push local 2 -> arg2 = '2'
+	i64, _ := strconv.ParseInt(p.fields[2], 10, 0)
+	return int(i64)
+}
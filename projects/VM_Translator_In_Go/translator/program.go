@@ -0,0 +1,127 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Where a command came from, e.g. Main.vm:12
+type Position struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%v", p.File, p.Line)
+}
+
+// A single VM command. Only the fields for its Type are set, e.g.
+// push local 2 -> {Type: C_PUSH, Segment: "local", Index: 2}
+type Command struct {
+	Type CommandType
+	// The arithmetic/logical command: add, sub, neg, eq, gt, lt, and, or, not
+	Op string
+	// push/pop segment index
+	Segment string
+	Index   int
+	// label, goto and if-goto
+	Label string
+	// function name nVars, call name nArgs
+	Function string
+	NVars    int
+	NArgs    int
+	// C_MOVE copies Segment/Index to DestSegment/DestIndex
+	DestSegment string
+	DestIndex   int
+	// C_ADD_CONSTANT adds Value to the top of the stack
+	Value int
+	// The source line, including any trailing comment
+	Text   string
+	Source Position
+}
+
+// The command in its canonical VM form, e.g. "push local 2"
+func (c Command) String() string {
+	switch c.Type {
+	case C_PUSH:
+		return fmt.Sprintf("push %s %v", c.Segment, c.Index)
+	case C_POP:
+		return fmt.Sprintf("pop %s %v", c.Segment, c.Index)
+	case C_LABEL:
+		return "label " + c.Label
+	case C_GOTO:
+		return "goto " + c.Label
+	case C_IF:
+		return "if-goto " + c.Label
+	case C_FUNCTION:
+		return fmt.Sprintf("function %s %v", c.Function, c.NVars)
+	case C_CALL:
+		return fmt.Sprintf("call %s %v", c.Function, c.NArgs)
+	case C_RETURN:
+		return "return"
+	case C_HALT:
+		return "halt"
+	case C_MOVE:
+		return fmt.Sprintf("move %s %v to %s %v", c.Segment, c.Index, c.DestSegment, c.DestIndex)
+	case C_ADD_CONSTANT:
+		return fmt.Sprintf("add constant %v", c.Value)
+	}
+	return c.Op
+}
+
+// The commands of a single .vm file
+type Module struct {
+	// The file's name without its directory, e.g. Main.vm
+	Name     string
+	Path     string
+	Commands []Command
+}
+
+// Every module being translated into a single .asm file
+type Program struct {
+	Modules []*Module
+}
+
+// Parses a .vm file, returning every invalid command in it joined
+// together with errors.Join
+func ParseFile(vmFilename string) (*Module, error) {
+	p, err := NewParser(vmFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	module := &Module{Name: p.FileName(), Path: vmFilename}
+	errs := []error{}
+	for p.HasMoreLines() {
+		if err := p.Advance(); err != nil {
+			return nil, err
+		}
+		if !p.HasCommand() {
+			continue
+		}
+		cmd, err := p.Command()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		module.Commands = append(module.Commands, cmd)
+	}
+	return module, errors.Join(errs...)
+}
+
+// Parses every .vm file, returning the errors from all of them
+func ParseProgram(vmFiles []string) (*Program, error) {
+	program := &Program{}
+	errs := []error{}
+	for _, vmFile := range vmFiles {
+		module, err := ParseFile(vmFile)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if module != nil {
+			program.Modules = append(program.Modules, module)
+		}
+	}
+	return program, errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// In OptimizeSize mode call, return and the comparisons jump into a single
+// copy of their code, emitted once at the end of the program, passing
+// their arguments in R13-R15:
+//
+//	$CALL:    R13 = function, R14 = nArgs, D = return address
+//	$RETURN:  no arguments, uses R13 and R14 itself
+//	$COMPARE: R14 = 0 for eq, 1 for gt, -1 for lt, R15 = return address
+const (
+	callRoutine    = generatedPrefix + "CALL"
+	returnRoutine  = generatedPrefix + "RETURN"
+	compareRoutine = generatedPrefix + "COMPARE"
+)
+
+func (cw *CodeWriter) writeSharedCall(functionName string, nArgs int) {
+	cw.usedRoutines[callRoutine] = true
+	id := cw.callCount
+	cw.callCount += 1
+	returnAddress := fmt.Sprintf("%s%s$ret.%v", generatedPrefix, cw.scope(), id)
+
+	cmds := []string{}
+	cmds = append(cmds, fmt.Sprintf("// call %s %v", functionName, nArgs))
+	cmds = append(cmds, "@"+functionName)
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, fmt.Sprintf("@%v", nArgs))
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@"+returnAddress)
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@"+callRoutine)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, fmt.Sprintf("(%s)", returnAddress))
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) writeSharedReturn() {
+	cw.usedRoutines[returnRoutine] = true
+
+	cmds := []string{}
+	cmds = append(cmds, "// return")
+	cmds = append(cmds, "@"+returnRoutine)
+	cmds = append(cmds, "0;JMP")
+	cw.appendASMCommands(cmds)
+}
+
+func (cw *CodeWriter) writeSharedComparison(command string) {
+	cw.usedRoutines[compareRoutine] = true
+	id := cw.callCount
+	cw.callCount += 1
+	returnAddress := fmt.Sprintf("%s%s_RET.%v", generatedPrefix, strings.ToUpper(command), id)
+
+	cmds := []string{}
+	cmds = append(cmds, "// "+command)
+	cmds = append(cmds, "@"+returnAddress)
+	cmds = append(cmds, "D=A")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@R14")
+	switch command {
+	case "eq":
+		cmds = append(cmds, "M=0")
+	case "gt":
+		cmds = append(cmds, "M=1")
+	case "lt":
+		cmds = append(cmds, "M=-1")
+	}
+	cmds = append(cmds, "@"+compareRoutine)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, fmt.Sprintf("(%s)", returnAddress))
+	cw.appendASMCommands(cmds)
+}
+
+// Emits the shared routines used by the program, if any. Must come after
+// all the program's code, where it can only be reached by jumping to it.
+func (cw *CodeWriter) WriteSharedRoutines() {
+	if cw.usedRoutines[callRoutine] {
+		cw.appendASMCommands(callRoutineCommands())
+	}
+	if cw.usedRoutines[returnRoutine] {
+		cmds := []string{}
+		cmds = append(cmds, "// Shared return routine")
+		cmds = append(cmds, "("+returnRoutine+")")
+		cmds = append(cmds, returnCommands()...)
+		cw.appendASMCommands(cmds)
+	}
+	if cw.usedRoutines[compareRoutine] {
+		cw.appendASMCommands(cw.compareRoutineCommands())
+	}
+	cw.writeExtensionRoutines()
+}
+
+func callRoutineCommands() []string {
+	cmds := []string{}
+	cmds = append(cmds, "// Shared call routine")
+	cmds = append(cmds, "("+callRoutine+")")
+	// Push the return address, from D
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "M=M+1")
+	// Push the caller's LCL, ARG, THIS and THAT
+	for _, pointer := range []string{"LCL", "ARG", "THIS", "THAT"} {
+		cmds = append(cmds, "@"+pointer)
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M")
+		cmds = append(cmds, "M=D")
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "M=M+1")
+	}
+	// ARG = SP - 5 - nArgs
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@5")
+	cmds = append(cmds, "D=D-A")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=D-M")
+	cmds = append(cmds, "@ARG")
+	cmds = append(cmds, "M=D")
+	// LCL = SP
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@LCL")
+	cmds = append(cmds, "M=D")
+	// Jump to the function
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "0;JMP")
+	return cmds
+}
+
+func (cw *CodeWriter) compareRoutineCommands() []string {
+	isTrue := compareRoutine + "_TRUE"
+	isFalse := compareRoutine + "_FALSE"
+	equal := compareRoutine + "_EQ"
+	lessThan := compareRoutine + "_LT"
+
+	cmds := []string{}
+	cmds = append(cmds, "// Shared compare routine")
+	cmds = append(cmds, "("+compareRoutine+")")
+	// D = x-y, or something with the same sign if it could overflow
+	if cw.opts.FastComparisons {
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "AM=M-1")
+		cmds = append(cmds, "D=M")
+		cmds = append(cmds, "@SP")
+		cmds = append(cmds, "A=M-1")
+		cmds = append(cmds, "D=M-D")
+	} else {
+		cmds = append(cmds, cw.compareSigns(compareRoutine, "")...)
+	}
+	// Keep the difference in R13 while picking the comparison
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+equal)
+	cmds = append(cmds, "D;JEQ")
+	cmds = append(cmds, "@"+lessThan)
+	cmds = append(cmds, "D;JLT")
+	// gt
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+isTrue)
+	cmds = append(cmds, "D;JGT")
+	cmds = append(cmds, "@"+isFalse)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+lessThan+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+isTrue)
+	cmds = append(cmds, "D;JLT")
+	cmds = append(cmds, "@"+isFalse)
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+equal+")")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@"+isTrue)
+	cmds = append(cmds, "D;JEQ")
+	// Replace x with the result and return to R15
+	cmds = append(cmds, "("+isFalse+")")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=0")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "0;JMP")
+	cmds = append(cmds, "("+isTrue+")")
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "A=M-1")
+	cmds = append(cmds, "M=-1")
+	cmds = append(cmds, "@R15")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "0;JMP")
+	return cmds
+}
+
+// The number of Hack instructions, i.e. lines that aren't comments or labels
+func countInstructions(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "(") {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,160 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The stack depths of a function, relative to its frame
+type StackDepth struct {
+	Function string
+	Source   Position
+	MaxDepth int
+}
+
+// Tracks the stack depth through every function's control flow, reporting
+// labels reached with different depths, commands that pop more than the
+// function has pushed and returns with nothing to return. Code outside of
+// any function is checked as if it was a function named after its module.
+// Returns each function's maximum depth, and every problem found joined
+// together with errors.Join.
+func VerifyStack(program *Program) ([]StackDepth, error) {
+	depths := []StackDepth{}
+	errs := []error{}
+	for _, module := range program.Modules {
+		for _, body := range splitFunctions(module) {
+			depth, err := verifyFunctionStack(body)
+			depths = append(depths, depth)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return depths, errors.Join(errs...)
+}
+
+// A function's commands, after its function command
+type functionBody struct {
+	name     string
+	source   Position
+	commands []Command
+}
+
+func splitFunctions(module *Module) []*functionBody {
+	// Code before the module's first function, if there is any
+	topLevel := &functionBody{name: moduleName(module.Name)}
+	bodies := []*functionBody{}
+	current := topLevel
+	for _, cmd := range module.Commands {
+		if cmd.Type == C_FUNCTION {
+			current = &functionBody{name: cmd.Function, source: cmd.Source}
+			bodies = append(bodies, current)
+			continue
+		}
+		if current == topLevel && len(topLevel.commands) == 0 {
+			topLevel.source = cmd.Source
+		}
+		current.commands = append(current.commands, cmd)
+	}
+
+	if len(topLevel.commands) > 0 {
+		bodies = append([]*functionBody{topLevel}, bodies...)
+	}
+	return bodies
+}
+
+// How many values a command needs on the stack, and how it changes the depth
+func stackEffect(cmd Command) (needs int, change int) {
+	switch cmd.Type {
+	case C_PUSH:
+		return 0, 1
+	case C_POP, C_IF:
+		return 1, -1
+	case C_ADD_CONSTANT:
+		return 1, 0
+	case C_CALL:
+		// The arguments are replaced by the return value
+		return cmd.NArgs, 1 - cmd.NArgs
+	case C_RETURN:
+		return 1, 0
+	case C_ARITHMETIC:
+		if cmd.Op == "neg" || cmd.Op == "not" {
+			return 1, 0
+		}
+		return 2, -1
+	}
+	return 0, 0
+}
+
+func verifyFunctionStack(body *functionBody) (StackDepth, error) {
+	result := StackDepth{Function: body.name, Source: body.source}
+	errs := []error{}
+	report := func(cmd Command, format string, args ...any) {
+		errs = append(errs, &SourceError{Source: cmd.Source, Text: cmd.String(),
+			Message: fmt.Sprintf("in %s, ", body.name) + fmt.Sprintf(format, args...)})
+	}
+
+	labels := map[string]int{}
+	for i, cmd := range body.commands {
+		if cmd.Type == C_LABEL {
+			labels[cmd.Label] = i
+		}
+	}
+
+	// The depth before each command, once reached
+	depths := make([]int, len(body.commands))
+	reached := make([]bool, len(body.commands))
+	mismatched := map[int]bool{}
+	worklist := []int{}
+	reach := func(i int, depth int) {
+		if i >= len(body.commands) {
+			return
+		}
+		if !reached[i] {
+			reached[i] = true
+			depths[i] = depth
+			worklist = append(worklist, i)
+		} else if depths[i] != depth && !mismatched[i] {
+			mismatched[i] = true
+			report(body.commands[i], "reached with stack depth %v and %v", depths[i], depth)
+		}
+	}
+
+	reach(0, 0)
+	for len(worklist) > 0 {
+		i := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		cmd := body.commands[i]
+		depth := depths[i]
+
+		needs, change := stackEffect(cmd)
+		if cmd.Type == C_RETURN && depth == 0 {
+			report(cmd, "return with an empty stack")
+			continue
+		}
+		if depth < needs {
+			report(cmd, "stack underflow, needs %v values but only %v pushed", needs, depth)
+			continue
+		}
+		depth += change
+		if depth > result.MaxDepth {
+			result.MaxDepth = depth
+		}
+
+		switch cmd.Type {
+		case C_RETURN, C_HALT:
+		case C_GOTO:
+			if target, exists := labels[cmd.Label]; exists {
+				reach(target, depth)
+			}
+		case C_IF:
+			if target, exists := labels[cmd.Label]; exists {
+				reach(target, depth)
+			}
+			reach(i+1, depth)
+		default:
+			reach(i+1, depth)
+		}
+	}
+	return result, errors.Join(errs...)
+}
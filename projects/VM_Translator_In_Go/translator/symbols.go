@@ -0,0 +1,103 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+// Every label the translator generates starts with this. VM symbols can't
+// contain a "$", so these never collide with anything in the program.
+const generatedPrefix = "$"
+
+// The program's halt loop, which the end of the code and any halt commands
+// jump to
+const haltLabel = generatedPrefix + "END"
+
+// The module's name as used for its statics, e.g. Main for Main.vm
+func moduleName(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// static 3 in Main.vm -> Main.3
+func staticSymbol(module string, index int) string {
+	return fmt.Sprintf("%s.%v", module, index)
+}
+
+// label LOOP in function Main.main -> Main.main$LOOP
+func labelSymbol(scope string, label string) string {
+	return scope + "$" + label
+}
+
+type symbolDefinition struct {
+	kind   string // function, label, static or predefined
+	owner  string // The module that uses a static
+	source Position
+}
+
+// Checks that the program's functions, labels and statics all map to
+// distinct assembly symbols, none of them one of the Hack predefined symbols
+// like SP, R1 or SCREEN, and that every goto targets a label in its own
+// function. Returns every problem found, joined together with errors.Join.
+func checkSymbols(program *Program) error {
+	errs := []error{}
+	defined := map[string]symbolDefinition{}
+	for symbol := range assembler.DefaultMemoryMap().Symbols {
+		defined[symbol] = symbolDefinition{kind: "predefined"}
+	}
+	define := func(symbol string, definition symbolDefinition, cmd Command) {
+		previous, exists := defined[symbol]
+		if !exists {
+			defined[symbol] = definition
+			return
+		}
+		if definition.kind == "static" && previous.kind == "static" && previous.owner == definition.owner {
+			return
+		}
+		message := fmt.Sprintf("%s %s collides with the %s at %v", definition.kind, symbol, previous.kind, previous.source)
+		if previous.kind == "predefined" {
+			message = fmt.Sprintf("%s %s collides with the predefined Hack symbol", definition.kind, symbol)
+		}
+		errs = append(errs, &SourceError{Source: cmd.Source, Text: cmd.String(), Message: message})
+	}
+
+	// Labels can be defined after the gotos that use them, so these are
+	// only checked once every label is known
+	type labelReference struct {
+		symbol string
+		scope  string
+		cmd    Command
+	}
+	references := []labelReference{}
+
+	for _, module := range program.Modules {
+		scope := moduleName(module.Name)
+		for _, cmd := range module.Commands {
+			switch cmd.Type {
+			case C_FUNCTION:
+				scope = cmd.Function
+				define(cmd.Function, symbolDefinition{kind: "function", source: cmd.Source}, cmd)
+			case C_LABEL:
+				define(labelSymbol(scope, cmd.Label), symbolDefinition{kind: "label", source: cmd.Source}, cmd)
+			case C_GOTO, C_IF:
+				references = append(references, labelReference{symbol: labelSymbol(scope, cmd.Label), scope: scope, cmd: cmd})
+			case C_PUSH, C_POP:
+				if cmd.Segment == "static" {
+					symbol := staticSymbol(moduleName(module.Name), cmd.Index)
+					define(symbol, symbolDefinition{kind: "static", owner: module.Path, source: cmd.Source}, cmd)
+				}
+			}
+		}
+	}
+
+	for _, reference := range references {
+		if definition, exists := defined[reference.symbol]; !exists || definition.kind != "label" {
+			errs = append(errs, &SourceError{Source: reference.cmd.Source, Text: reference.cmd.String(),
+				Message: fmt.Sprintf("no label %s in %s", reference.cmd.Label, reference.scope)})
+		}
+	}
+	return errors.Join(errs...)
+}
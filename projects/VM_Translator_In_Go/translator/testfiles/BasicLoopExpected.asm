@@ -19,8 +19,8 @@ D=M
 @R13
 A=M
 M=D
-// label BasicLoop.vm$LOOP_START
-(BasicLoop.vm$LOOP_START)
+// label BasicLoop$LOOP_START
+(BasicLoop$LOOP_START)
 // push argument 0
 @ARG
 D=M
@@ -49,7 +49,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // pop local 0	        // sum = sum + counter
@@ -117,11 +117,11 @@ A=M
 M=D
 @SP
 M=M+1
-// if-goto BasicLoop.vm$LOOP_START
+// if-goto BasicLoop$LOOP_START
 @SP
 AM=M-1
 D=M
-@BasicLoop.vm$LOOP_START
+@BasicLoop$LOOP_START
 D;JNE
 // push local 0
 @LCL
@@ -135,6 +135,6 @@ M=D
 @SP
 M=M+1
 // Infinite Loop
-(END)
-@END
+($END)
+@$END
 0;JMP
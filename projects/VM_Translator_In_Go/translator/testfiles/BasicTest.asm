@@ -166,7 +166,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // push argument 1
@@ -217,7 +217,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // sub
@@ -243,10 +243,10 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // Infinite Loop
-(END)
-@END
+($END)
+@$END
 0;JMP
@@ -3,7 +3,7 @@ D=A
 @SP
 M=D
 // call Sys.init 0
-@Sys.init$ret0
+@$Bootstrap$ret.0
 D=A
 @SP
 A=M
@@ -52,16 +52,16 @@ D=M
 M=D
 @Sys.init
 0;JMP
-(Sys.init$ret0)
+($Bootstrap$ret.0)
 // function Main.fibonacci 0
 (Main.fibonacci)
 @0
 D=A
-@Main.fibonacci$INIT_END
+@$Main.fibonacci$INIT_END
 D;JLE
 @R13
 M=D
-(Main.fibonacci$INIT_LOOP)
+($Main.fibonacci$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -69,9 +69,9 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Main.fibonacci$INIT_LOOP
+@$Main.fibonacci$INIT_LOOP
 D;JGT
-(Main.fibonacci$INIT_END)
+($Main.fibonacci$INIT_END)
 // push argument 0
 @ARG
 D=M
@@ -95,32 +95,58 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@LT_1_TRUE
+D=M
+@$LT_XNEG.1
+D;JLT
+@R13
+D=M
+@$LT_SUB.1
+D;JGE
+D=1
+@$LT_CMP.1
+0;JMP
+($LT_XNEG.1)
+@R13
+D=M
+@$LT_SUB.1
+D;JLT
+D=-1
+@$LT_CMP.1
+0;JMP
+($LT_SUB.1)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($LT_CMP.1)
+@$LT_TRUE.1
 D;JLT
 @SP
 A=M-1
 M=0
-@LT_1_END
+@$LT_END.1
 0;JMP
-(LT_1_TRUE)
+($LT_TRUE.1)
 @SP
 A=M-1
 M=-1
-(LT_1_END)
-// if-goto Main.vm$IF_TRUE
+($LT_END.1)
+// if-goto Main.fibonacci$IF_TRUE
 @SP
 AM=M-1
 D=M
-@Main.vm$IF_TRUE
+@Main.fibonacci$IF_TRUE
 D;JNE
-// goto Main.vm$IF_FALSE
-@Main.vm$IF_FALSE
+// goto Main.fibonacci$IF_FALSE
+@Main.fibonacci$IF_FALSE
 0;JMP
-// label Main.vm$IF_TRUE
-(Main.vm$IF_TRUE)
+// label Main.fibonacci$IF_TRUE
+(Main.fibonacci$IF_TRUE)
 // push argument 0
 @ARG
 D=M
@@ -186,8 +212,8 @@ M=D
 @R14
 A=M
 0;JMP
-// label Main.vm$IF_FALSE
-(Main.vm$IF_FALSE)
+// label Main.fibonacci$IF_FALSE
+(Main.fibonacci$IF_FALSE)
 // push argument 0
 @ARG
 D=M
@@ -217,7 +243,7 @@ M=M-D
 @SP
 M=M+1
 // call Main.fibonacci 1
-@Main.fibonacci$ret2
+@$Main.fibonacci$ret.2
 D=A
 @SP
 A=M
@@ -266,7 +292,7 @@ D=M
 M=D
 @Main.fibonacci
 0;JMP
-(Main.fibonacci$ret2)
+($Main.fibonacci$ret.2)
 // push argument 0
 @ARG
 D=M
@@ -296,7 +322,7 @@ M=M-D
 @SP
 M=M+1
 // call Main.fibonacci 1
-@Main.fibonacci$ret3
+@$Main.fibonacci$ret.3
 D=A
 @SP
 A=M
@@ -345,14 +371,14 @@ D=M
 M=D
 @Main.fibonacci
 0;JMP
-(Main.fibonacci$ret3)
+($Main.fibonacci$ret.3)
 // add
 @SP
 AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // return
@@ -413,11 +439,11 @@ A=M
 (Sys.init)
 @0
 D=A
-@Sys.init$INIT_END
+@$Sys.init$INIT_END
 D;JLE
 @R13
 M=D
-(Sys.init$INIT_LOOP)
+($Sys.init$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -425,9 +451,9 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Sys.init$INIT_LOOP
+@$Sys.init$INIT_LOOP
 D;JGT
-(Sys.init$INIT_END)
+($Sys.init$INIT_END)
 // push constant 4
 @4
 D=A
@@ -437,7 +463,7 @@ M=D
 @SP
 M=M+1
 // call Main.fibonacci 1
-@Main.fibonacci$ret4
+@$Sys.init$ret.4
 D=A
 @SP
 A=M
@@ -486,9 +512,9 @@ D=M
 M=D
 @Main.fibonacci
 0;JMP
-(Main.fibonacci$ret4)
-// label Sys.vm$WHILE
-(Sys.vm$WHILE)
-// goto Sys.vm$WHILE
-@Sys.vm$WHILE
+($Sys.init$ret.4)
+// label Sys.init$WHILE
+(Sys.init$WHILE)
+// goto Sys.init$WHILE
+@Sys.init$WHILE
 0;JMP
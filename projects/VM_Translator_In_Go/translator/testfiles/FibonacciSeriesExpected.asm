@@ -98,8 +98,8 @@ D=M
 @R13
 A=M
 M=D
-// label FibonacciSeries.vm$MAIN_LOOP_START
-(FibonacciSeries.vm$MAIN_LOOP_START)
+// label FibonacciSeries$MAIN_LOOP_START
+(FibonacciSeries$MAIN_LOOP_START)
 // push argument 0
 @ARG
 D=M
@@ -111,17 +111,17 @@ A=M
 M=D
 @SP
 M=M+1
-// if-goto FibonacciSeries.vm$COMPUTE_ELEMENT
+// if-goto FibonacciSeries$COMPUTE_ELEMENT
 @SP
 AM=M-1
 D=M
-@FibonacciSeries.vm$COMPUTE_ELEMENT
+@FibonacciSeries$COMPUTE_ELEMENT
 D;JNE
-// goto FibonacciSeries.vm$END_PROGRAM
-@FibonacciSeries.vm$END_PROGRAM
+// goto FibonacciSeries$END_PROGRAM
+@FibonacciSeries$END_PROGRAM
 0;JMP
-// label FibonacciSeries.vm$COMPUTE_ELEMENT
-(FibonacciSeries.vm$COMPUTE_ELEMENT)
+// label FibonacciSeries$COMPUTE_ELEMENT
+(FibonacciSeries$COMPUTE_ELEMENT)
 // push that 0
 @THAT
 D=M
@@ -150,7 +150,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // pop that 2              // that[2] = that[0] + that[1]
@@ -188,7 +188,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // pop pointer 1           // that += 1
@@ -238,12 +238,12 @@ D=M
 @R13
 A=M
 M=D
-// goto FibonacciSeries.vm$MAIN_LOOP_START
-@FibonacciSeries.vm$MAIN_LOOP_START
+// goto FibonacciSeries$MAIN_LOOP_START
+@FibonacciSeries$MAIN_LOOP_START
 0;JMP
-// label FibonacciSeries.vm$END_PROGRAM
-(FibonacciSeries.vm$END_PROGRAM)
+// label FibonacciSeries$END_PROGRAM
+(FibonacciSeries$END_PROGRAM)
 // Infinite Loop
-(END)
-@END
+($END)
+@$END
 0;JMP
@@ -20,10 +20,10 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // Infinite Loop
-(END)
-@END
+($END)
+@$END
 0;JMP
@@ -2,11 +2,11 @@
 (SimpleFunction.test)
 @2
 D=A
-@SimpleFunction.test$INIT_END
+@$SimpleFunction.test$INIT_END
 D;JLE
 @R13
 M=D
-(SimpleFunction.test$INIT_LOOP)
+($SimpleFunction.test$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -14,9 +14,9 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@SimpleFunction.test$INIT_LOOP
+@$SimpleFunction.test$INIT_LOOP
 D;JGT
-(SimpleFunction.test$INIT_END)
+($SimpleFunction.test$INIT_END)
 // push local 0
 @LCL
 D=M
@@ -45,7 +45,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // not
@@ -69,7 +69,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // push argument 1
@@ -147,6 +147,6 @@ M=D
 A=M
 0;JMP
 // Infinite Loop
-(END)
-@END
+($END)
+@$END
 0;JMP
@@ -21,18 +21,18 @@ D=M
 @SP
 A=M-1
 D=M-D
-@EQ_1_TRUE
+@$EQ_TRUE.0
 D;JEQ
 @SP
 A=M-1
 M=0
-@EQ_1_END
+@$EQ_END.0
 0;JMP
-(EQ_1_TRUE)
+($EQ_TRUE.0)
 @SP
 A=M-1
 M=-1
-(EQ_1_END)
+($EQ_END.0)
 // push constant 17
 @17
 D=A
@@ -56,18 +56,18 @@ D=M
 @SP
 A=M-1
 D=M-D
-@EQ_2_TRUE
+@$EQ_TRUE.1
 D;JEQ
 @SP
 A=M-1
 M=0
-@EQ_2_END
+@$EQ_END.1
 0;JMP
-(EQ_2_TRUE)
+($EQ_TRUE.1)
 @SP
 A=M-1
 M=-1
-(EQ_2_END)
+($EQ_END.1)
 // push constant 16
 @16
 D=A
@@ -91,18 +91,18 @@ D=M
 @SP
 A=M-1
 D=M-D
-@EQ_3_TRUE
+@$EQ_TRUE.2
 D;JEQ
 @SP
 A=M-1
 M=0
-@EQ_3_END
+@$EQ_END.2
 0;JMP
-(EQ_3_TRUE)
+($EQ_TRUE.2)
 @SP
 A=M-1
 M=-1
-(EQ_3_END)
+($EQ_END.2)
 // push constant 892
 @892
 D=A
@@ -123,21 +123,47 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@LT_4_TRUE
+D=M
+@$LT_XNEG.3
+D;JLT
+@R13
+D=M
+@$LT_SUB.3
+D;JGE
+D=1
+@$LT_CMP.3
+0;JMP
+($LT_XNEG.3)
+@R13
+D=M
+@$LT_SUB.3
+D;JLT
+D=-1
+@$LT_CMP.3
+0;JMP
+($LT_SUB.3)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($LT_CMP.3)
+@$LT_TRUE.3
 D;JLT
 @SP
 A=M-1
 M=0
-@LT_4_END
+@$LT_END.3
 0;JMP
-(LT_4_TRUE)
+($LT_TRUE.3)
 @SP
 A=M-1
 M=-1
-(LT_4_END)
+($LT_END.3)
 // push constant 891
 @891
 D=A
@@ -158,21 +184,47 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@LT_5_TRUE
+D=M
+@$LT_XNEG.4
+D;JLT
+@R13
+D=M
+@$LT_SUB.4
+D;JGE
+D=1
+@$LT_CMP.4
+0;JMP
+($LT_XNEG.4)
+@R13
+D=M
+@$LT_SUB.4
+D;JLT
+D=-1
+@$LT_CMP.4
+0;JMP
+($LT_SUB.4)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($LT_CMP.4)
+@$LT_TRUE.4
 D;JLT
 @SP
 A=M-1
 M=0
-@LT_5_END
+@$LT_END.4
 0;JMP
-(LT_5_TRUE)
+($LT_TRUE.4)
 @SP
 A=M-1
 M=-1
-(LT_5_END)
+($LT_END.4)
 // push constant 891
 @891
 D=A
@@ -193,21 +245,47 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@LT_6_TRUE
+D=M
+@$LT_XNEG.5
+D;JLT
+@R13
+D=M
+@$LT_SUB.5
+D;JGE
+D=1
+@$LT_CMP.5
+0;JMP
+($LT_XNEG.5)
+@R13
+D=M
+@$LT_SUB.5
+D;JLT
+D=-1
+@$LT_CMP.5
+0;JMP
+($LT_SUB.5)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($LT_CMP.5)
+@$LT_TRUE.5
 D;JLT
 @SP
 A=M-1
 M=0
-@LT_6_END
+@$LT_END.5
 0;JMP
-(LT_6_TRUE)
+($LT_TRUE.5)
 @SP
 A=M-1
 M=-1
-(LT_6_END)
+($LT_END.5)
 // push constant 32767
 @32767
 D=A
@@ -228,21 +306,47 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@GT_7_TRUE
+D=M
+@$GT_XNEG.6
+D;JLT
+@R13
+D=M
+@$GT_SUB.6
+D;JGE
+D=1
+@$GT_CMP.6
+0;JMP
+($GT_XNEG.6)
+@R13
+D=M
+@$GT_SUB.6
+D;JLT
+D=-1
+@$GT_CMP.6
+0;JMP
+($GT_SUB.6)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($GT_CMP.6)
+@$GT_TRUE.6
 D;JGT
 @SP
 A=M-1
 M=0
-@GT_7_END
+@$GT_END.6
 0;JMP
-(GT_7_TRUE)
+($GT_TRUE.6)
 @SP
 A=M-1
 M=-1
-(GT_7_END)
+($GT_END.6)
 // push constant 32766
 @32766
 D=A
@@ -263,21 +367,47 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@GT_8_TRUE
+D=M
+@$GT_XNEG.7
+D;JLT
+@R13
+D=M
+@$GT_SUB.7
+D;JGE
+D=1
+@$GT_CMP.7
+0;JMP
+($GT_XNEG.7)
+@R13
+D=M
+@$GT_SUB.7
+D;JLT
+D=-1
+@$GT_CMP.7
+0;JMP
+($GT_SUB.7)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($GT_CMP.7)
+@$GT_TRUE.7
 D;JGT
 @SP
 A=M-1
 M=0
-@GT_8_END
+@$GT_END.7
 0;JMP
-(GT_8_TRUE)
+($GT_TRUE.7)
 @SP
 A=M-1
 M=-1
-(GT_8_END)
+($GT_END.7)
 // push constant 32766
 @32766
 D=A
@@ -298,21 +428,47 @@ M=M+1
 @SP
 AM=M-1
 D=M
+@R13
+M=D
 @SP
 A=M-1
-D=M-D
-@GT_9_TRUE
+D=M
+@$GT_XNEG.8
+D;JLT
+@R13
+D=M
+@$GT_SUB.8
+D;JGE
+D=1
+@$GT_CMP.8
+0;JMP
+($GT_XNEG.8)
+@R13
+D=M
+@$GT_SUB.8
+D;JLT
+D=-1
+@$GT_CMP.8
+0;JMP
+($GT_SUB.8)
+@SP
+A=M-1
+D=M
+@R13
+D=D-M
+($GT_CMP.8)
+@$GT_TRUE.8
 D;JGT
 @SP
 A=M-1
 M=0
-@GT_9_END
+@$GT_END.8
 0;JMP
-(GT_9_TRUE)
+($GT_TRUE.8)
 @SP
 A=M-1
 M=-1
-(GT_9_END)
+($GT_END.8)
 // push constant 57
 @57
 D=A
@@ -343,7 +499,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M+D
+M=D+M
 @SP
 M=M+1
 // push constant 112
@@ -373,7 +529,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M&D
+M=D&M
 @SP
 M=M+1
 // push constant 82
@@ -390,7 +546,7 @@ AM=M-1
 D=M
 @SP
 AM=M-1
-M=M|D
+M=D|M
 @SP
 M=M+1
 // not
@@ -398,6 +554,6 @@ M=M+1
 A=M-1
 M=!M
 // Infinite Loop
-(END)
-@END
+($END)
+@$END
 0;JMP
@@ -3,7 +3,7 @@ D=A
 @SP
 M=D
 // call Sys.init 0
-@Sys.init$ret0
+@$Bootstrap$ret.0
 D=A
 @SP
 A=M
@@ -52,16 +52,16 @@ D=M
 M=D
 @Sys.init
 0;JMP
-(Sys.init$ret0)
+($Bootstrap$ret.0)
 // function Class1.set 0
 (Class1.set)
 @0
 D=A
-@Class1.set$INIT_END
+@$Class1.set$INIT_END
 D;JLE
 @R13
 M=D
-(Class1.set$INIT_LOOP)
+($Class1.set$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -69,9 +69,9 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Class1.set$INIT_LOOP
+@$Class1.set$INIT_LOOP
 D;JGT
-(Class1.set$INIT_END)
+($Class1.set$INIT_END)
 // push argument 0
 @ARG
 D=M
@@ -87,7 +87,7 @@ M=M+1
 @SP
 AM=M-1
 D=M
-@Class1.vm.0
+@Class1.0
 M=D
 // push argument 1
 @ARG
@@ -104,7 +104,7 @@ M=M+1
 @SP
 AM=M-1
 D=M
-@Class1.vm.1
+@Class1.1
 M=D
 // push constant 0
 @0
@@ -172,11 +172,11 @@ A=M
 (Class1.get)
 @0
 D=A
-@Class1.get$INIT_END
+@$Class1.get$INIT_END
 D;JLE
 @R13
 M=D
-(Class1.get$INIT_LOOP)
+($Class1.get$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -184,11 +184,11 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Class1.get$INIT_LOOP
+@$Class1.get$INIT_LOOP
 D;JGT
-(Class1.get$INIT_END)
+($Class1.get$INIT_END)
 // push static 0
-@Class1.vm.0
+@Class1.0
 D=M
 @SP
 A=M
@@ -196,7 +196,7 @@ M=D
 @SP
 M=M+1
 // push static 1
-@Class1.vm.1
+@Class1.1
 D=M
 @SP
 A=M
@@ -270,11 +270,11 @@ A=M
 (Class2.set)
 @0
 D=A
-@Class2.set$INIT_END
+@$Class2.set$INIT_END
 D;JLE
 @R13
 M=D
-(Class2.set$INIT_LOOP)
+($Class2.set$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -282,9 +282,9 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Class2.set$INIT_LOOP
+@$Class2.set$INIT_LOOP
 D;JGT
-(Class2.set$INIT_END)
+($Class2.set$INIT_END)
 // push argument 0
 @ARG
 D=M
@@ -300,7 +300,7 @@ M=M+1
 @SP
 AM=M-1
 D=M
-@Class2.vm.0
+@Class2.0
 M=D
 // push argument 1
 @ARG
@@ -317,7 +317,7 @@ M=M+1
 @SP
 AM=M-1
 D=M
-@Class2.vm.1
+@Class2.1
 M=D
 // push constant 0
 @0
@@ -385,11 +385,11 @@ A=M
 (Class2.get)
 @0
 D=A
-@Class2.get$INIT_END
+@$Class2.get$INIT_END
 D;JLE
 @R13
 M=D
-(Class2.get$INIT_LOOP)
+($Class2.get$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -397,11 +397,11 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Class2.get$INIT_LOOP
+@$Class2.get$INIT_LOOP
 D;JGT
-(Class2.get$INIT_END)
+($Class2.get$INIT_END)
 // push static 0
-@Class2.vm.0
+@Class2.0
 D=M
 @SP
 A=M
@@ -409,7 +409,7 @@ M=D
 @SP
 M=M+1
 // push static 1
-@Class2.vm.1
+@Class2.1
 D=M
 @SP
 A=M
@@ -483,11 +483,11 @@ A=M
 (Sys.init)
 @0
 D=A
-@Sys.init$INIT_END
+@$Sys.init$INIT_END
 D;JLE
 @R13
 M=D
-(Sys.init$INIT_LOOP)
+($Sys.init$INIT_LOOP)
 @SP
 A=M
 M=0
@@ -495,9 +495,9 @@ M=0
 M=M+1
 @R13
 MD=M-1
-@Sys.init$INIT_LOOP
+@$Sys.init$INIT_LOOP
 D;JGT
-(Sys.init$INIT_END)
+($Sys.init$INIT_END)
 // push constant 6
 @6
 D=A
@@ -515,7 +515,7 @@ M=D
 @SP
 M=M+1
 // call Class1.set 2
-@Class1.set$ret1
+@$Sys.init$ret.1
 D=A
 @SP
 A=M
@@ -564,7 +564,7 @@ D=M
 M=D
 @Class1.set
 0;JMP
-(Class1.set$ret1)
+($Sys.init$ret.1)
 // pop temp 0 // Dumps the return value
 @SP
 AM=M-1
@@ -588,7 +588,7 @@ M=D
 @SP
 M=M+1
 // call Class2.set 2
-@Class2.set$ret2
+@$Sys.init$ret.2
 D=A
 @SP
 A=M
@@ -637,7 +637,7 @@ D=M
 M=D
 @Class2.set
 0;JMP
-(Class2.set$ret2)
+($Sys.init$ret.2)
 // pop temp 0 // Dumps the return value
 @SP
 AM=M-1
@@ -645,7 +645,7 @@ D=M
 @R5
 M=D
 // call Class1.get 0
-@Class1.get$ret3
+@$Sys.init$ret.3
 D=A
 @SP
 A=M
@@ -694,9 +694,9 @@ D=M
 M=D
 @Class1.get
 0;JMP
-(Class1.get$ret3)
+($Sys.init$ret.3)
 // call Class2.get 0
-@Class2.get$ret4
+@$Sys.init$ret.4
 D=A
 @SP
 A=M
@@ -745,9 +745,9 @@ D=M
 M=D
 @Class2.get
 0;JMP
-(Class2.get$ret4)
-// label Sys.vm$WHILE
-(Sys.vm$WHILE)
-// goto Sys.vm$WHILE
-@Sys.vm$WHILE
+($Sys.init$ret.4)
+// label Sys.init$WHILE
+(Sys.init$WHILE)
+// goto Sys.init$WHILE
+@Sys.init$WHILE
 0;JMP
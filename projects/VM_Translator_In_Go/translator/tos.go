@@ -0,0 +1,180 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// With Options.CacheTOS the top of the stack is kept in D, rather than in
+// RAM, across consecutive commands that only work on the stack. The stack
+// is spilled back to RAM before anything that could be jumped to or from,
+// i.e. labels, gotos, calls, functions and returns, so those always see the
+// usual stack in RAM.
+
+// The most A=A+1 steps used to reach segment[index], beyond which its
+// address is calculated instead
+const maxAddressIncrements = 3
+
+// Writes the command with the top of the stack cached in D, if it's one
+// that can be. Otherwise spills the stack, returning false so the command
+// is written as usual.
+func (cw *CodeWriter) writeCached(cmd Command) bool {
+	cmds := []string{}
+	if cmd.Text != "" {
+		cmds = append(cmds, "// "+cmd.Text)
+	} else {
+		cmds = append(cmds, "// "+cmd.String())
+	}
+
+	switch {
+	case cmd.Type == C_PUSH:
+		cmds = append(cmds, cw.spillCommands()...)
+		cmds = append(cmds, cw.loadSegment(cmd.Segment, cmd.Index)...)
+		cw.cachedTOS = true
+	case cmd.Type == C_POP:
+		cmds = append(cmds, cw.fillCommands()...)
+		cmds = append(cmds, cw.storeD(cmd.Segment, cmd.Index)...)
+		cw.cachedTOS = false
+	case cmd.Type == C_IF:
+		cmds = append(cmds, cw.fillCommands()...)
+		cmds = append(cmds, "@"+labelSymbol(cw.scope(), cmd.Label))
+		cmds = append(cmds, "D;JNE")
+		cw.cachedTOS = false
+	case cmd.Type == C_ADD_CONSTANT:
+		cmds = append(cmds, cw.fillCommands()...)
+		switch cmd.Value {
+		case 1:
+			cmds = append(cmds, "D=D+1")
+		case -1:
+			cmds = append(cmds, "D=D-1")
+		case -32768:
+			// Too big for an A-instruction when negated
+			cmds = append(cmds, "@32767")
+			cmds = append(cmds, "A=!A")
+			cmds = append(cmds, "D=D+A")
+		default:
+			if cmd.Value < 0 {
+				cmds = append(cmds, fmt.Sprintf("@%v", -cmd.Value))
+				cmds = append(cmds, "D=D-A")
+			} else {
+				cmds = append(cmds, fmt.Sprintf("@%v", cmd.Value))
+				cmds = append(cmds, "D=D+A")
+			}
+		}
+		cw.cachedTOS = true
+	case cmd.Type == C_ARITHMETIC && cw.isCachedOp(cmd.Op):
+		cmds = append(cmds, cw.cachedArithmetic(cmd.Op)...)
+		cw.cachedTOS = true
+	default:
+		cw.spill()
+		return false
+	}
+
+	cw.appendASMCommands(cmds)
+	return true
+}
+
+// Whether the op works on the cached top of the stack. Safe comparisons and
+// the shared compare routine need the stack in RAM.
+func (cw *CodeWriter) isCachedOp(op string) bool {
+	switch op {
+	case "eq", "gt", "lt":
+		return !cw.opts.OptimizeSize && (op == "eq" || cw.opts.FastComparisons)
+	}
+	// The extension routines work on the stack in RAM
+	return !extensionOps[op]
+}
+
+func (cw *CodeWriter) cachedArithmetic(op string) []string {
+	cmds := cw.fillCommands()
+	switch op {
+	case "neg":
+		return append(cmds, "D=-D")
+	case "not":
+		return append(cmds, "D=!D")
+	}
+
+	// y is in D, pop x and leave x op y in D
+	cmds = append(cmds, "@SP")
+	cmds = append(cmds, "AM=M-1")
+	switch op {
+	case "add":
+		cmds = append(cmds, "D=D+M")
+	case "sub":
+		cmds = append(cmds, "D=M-D")
+	case "and":
+		cmds = append(cmds, "D=D&M")
+	case "or":
+		cmds = append(cmds, "D=D|M")
+	default:
+		// eq, gt and lt, which can be the fast x-y version here
+		id := cw.callCount
+		cw.callCount += 1
+		labelPrefix := fmt.Sprintf("%s%v", generatedPrefix, strings.ToUpper(op))
+		cmds = append(cmds, "D=M-D")
+		cmds = append(cmds, fmt.Sprintf("@%s_TRUE.%v", labelPrefix, id))
+		cmds = append(cmds, "D;J"+strings.ToUpper(op))
+		cmds = append(cmds, "D=0")
+		cmds = append(cmds, fmt.Sprintf("@%s_END.%v", labelPrefix, id))
+		cmds = append(cmds, "0;JMP")
+		cmds = append(cmds, fmt.Sprintf("(%s_TRUE.%v)", labelPrefix, id))
+		cmds = append(cmds, "D=-1")
+		cmds = append(cmds, fmt.Sprintf("(%s_END.%v)", labelPrefix, id))
+	}
+	return cmds
+}
+
+// Stores D in segment[index], using R13 and R14 if its address has to be
+// calculated
+func (cw *CodeWriter) storeD(segment string, index int) []string {
+	cmds := []string{}
+	if symbol, direct := cw.directSymbol(segment, index); direct {
+		cmds = append(cmds, "@"+symbol)
+		cmds = append(cmds, "M=D")
+		return cmds
+	}
+
+	base := cw.segmentMap[segment]
+	if index <= maxAddressIncrements {
+		cmds = append(cmds, "@"+base)
+		cmds = append(cmds, "A=M")
+		for i := 0; i < index; i++ {
+			cmds = append(cmds, "A=A+1")
+		}
+		cmds = append(cmds, "M=D")
+		return cmds
+	}
+
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "M=D")
+	cmds = append(cmds, cw.addressToR13(segment, index)...)
+	cmds = append(cmds, "@R14")
+	cmds = append(cmds, "D=M")
+	cmds = append(cmds, "@R13")
+	cmds = append(cmds, "A=M")
+	cmds = append(cmds, "M=D")
+	return cmds
+}
+
+// Moves the top of the stack into D, if it isn't already there
+func (cw *CodeWriter) fillCommands() []string {
+	if cw.cachedTOS {
+		return []string{}
+	}
+	return []string{"@SP", "AM=M-1", "D=M"}
+}
+
+// Pushes the cached top of the stack back to RAM, if there is one
+func (cw *CodeWriter) spillCommands() []string {
+	if !cw.cachedTOS {
+		return []string{}
+	}
+	return []string{"@SP", "A=M", "M=D", "@SP", "M=M+1"}
+}
+
+func (cw *CodeWriter) spill() {
+	if cw.cachedTOS {
+		cw.appendASMCommands(cw.spillCommands())
+		cw.cachedTOS = false
+	}
+}
@@ -0,0 +1,329 @@
+package translator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type CommandType int
+
+const (
+	C_ARITHMETIC CommandType = iota + 1 // EnumIndex = 1
+	C_PUSH                              // EnumIndex = 2
+	C_POP
+	C_LABEL
+	C_GOTO
+	C_IF
+	C_FUNCTION
+	C_RETURN
+	C_CALL
+	// Produced by the VM optimizer, not the parser
+	C_MOVE
+	C_ADD_CONSTANT
+	// Only accepted with Options.Halt
+	C_HALT
+)
+
+type Options struct {
+	// Emit the bootstrap code, SP=256 and call EntryFunction, for whole
+	// programs. The earlier project 7/8 test scripts set up the stack
+	// themselves.
+	Bootstrap bool
+	// Decide Bootstrap from the program instead: emit the bootstrap code
+	// only if EntryFunction is defined, e.g. by a Sys.vm
+	AutoBootstrap bool
+	// The pointers the bootstrap code sets up
+	Pointers Pointers
+	// Defaults to Prog.asm for a single Prog.vm input, or Dir/Dir.asm for a directory
+	OutputFile string
+	// 0 translates each command on its own, 1 also removes redundant
+	// instructions where one command's code meets the next, and 2 also
+	// optimizes the VM commands first, e.g. folding constants
+	OptimizationLevel int
+	// Leave out the "// push constant 7" style comments before each command
+	StripComments bool
+	// Compare with a single subtraction, which is shorter but gives the wrong
+	// answer for gt/lt when x-y overflows, e.g. -20000 < 20000
+	FastComparisons bool
+	// Jump into a single shared copy of the call, return and comparison code
+	// rather than inlining it every time
+	OptimizeSize bool
+	// Keep the top of the stack in D across consecutive stack commands,
+	// rather than in RAM, for faster code
+	CacheTOS bool
+	// Check that every function keeps its stack balanced, see VerifyStack
+	VerifyStack bool
+	// Leave out functions that can't be reached from EntryFunction
+	EliminateDeadFunctions bool
+	// The function the bootstrap code calls and dead function elimination
+	// starts from, Sys.init if not set
+	EntryFunction string
+	// Accept the mul, div, mod, shl and shr commands, see extensionOps
+	ExtendedArithmetic bool
+	// Accept the halt command, which stops the program by jumping to the
+	// halt loop at the end of the code
+	Halt bool
+	// Also write Prog.asm.map, mapping each line of the output back to the
+	// VM command it came from, for the assembler to chain its own map through
+	SourceMap bool
+	// If set, a summary of the generated code's size is written here
+	Report io.Writer
+}
+
+// The initial values of the stack and segment pointers set by the bootstrap
+// code, by name: SP, LCL, ARG, THIS or THAT. SP is 256 if not set, and the
+// others are left alone if not set.
+type Pointers map[string]int
+
+// The pointers the bootstrap code can set, in the order it sets them
+var bootstrapPointers = []string{"SP", "LCL", "ARG", "THIS", "THAT"}
+
+// Checks every pointer is one the bootstrap code sets, and fits in a 16-bit
+// word so it can be loaded
+func (p Pointers) check() error {
+	known := 0
+	for _, name := range bootstrapPointers {
+		value, ok := p[name]
+		if !ok {
+			continue
+		}
+		known++
+		if value < -32768 || value > 32767 {
+			return fmt.Errorf("bootstrap %s %v is out of range, it must be -32768 to 32767", name, value)
+		}
+	}
+	if known != len(p) {
+		return fmt.Errorf("unknown bootstrap pointer, expected SP, LCL, ARG, THIS or THAT")
+	}
+	return nil
+}
+
+// The entry function for a whole program, when Options.EntryFunction isn't set
+const DefaultEntryFunction = "Sys.init"
+
+// The bootstrap's SP, when Options.Pointers has no SP
+const DefaultStackPointer = 256
+
+func (opts Options) entryFunction() string {
+	if opts.EntryFunction == "" {
+		return DefaultEntryFunction
+	}
+	return opts.EntryFunction
+}
+
+// The highest supported Options.OptimizationLevel
+const MaxOptimizationLevel = 2
+
+// Translates the .vm files (or directories of .vm files) into a single .asm file
+func Translate(inputs []string, opts Options) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no input files")
+	}
+	if opts.OptimizationLevel < 0 || opts.OptimizationLevel > MaxOptimizationLevel {
+		return fmt.Errorf("unsupported optimization level: %v", opts.OptimizationLevel)
+	}
+	if err := opts.Pointers.check(); err != nil {
+		return err
+	}
+
+	vmFiles := []string{}
+	for _, input := range inputs {
+		files, err := FindVMFiles(input)
+		if err != nil {
+			return err
+		}
+		vmFiles = append(vmFiles, files...)
+	}
+
+	outputFile := opts.OutputFile
+	if outputFile == "" {
+		if len(inputs) > 1 {
+			return fmt.Errorf("an output file is needed when translating more than one input")
+		}
+		var err error
+		if outputFile, err = DefaultOutputFile(inputs[0]); err != nil {
+			return err
+		}
+	}
+
+	t, err := NewTranslator(vmFiles, outputFile, opts)
+	if err != nil {
+		return err
+	}
+	return t.Run()
+}
+
+// A single ".vm" file, or every ".vm" file in a directory
+func FindVMFiles(input string) ([]string, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{input}, nil
+	}
+
+	files, err := os.ReadDir(input)
+	if err != nil {
+		return nil, err
+	}
+
+	vmFiles := []string{}
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".vm" {
+			vmFiles = append(vmFiles, filepath.Join(input, file.Name()))
+		}
+	}
+	if len(vmFiles) == 0 {
+		return nil, fmt.Errorf("no .vm files in directory: %s", input)
+	}
+	return vmFiles, nil
+}
+
+// Prog.asm next to Prog.vm, or Dir/Dir.asm inside a directory
+func DefaultOutputFile(input string) (string, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return strings.TrimSuffix(input, filepath.Ext(input)) + ".asm", nil
+	}
+
+	// The directory's own name, even for inputs like "." or "Dir/"
+	dir, err := filepath.Abs(input)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(input, filepath.Base(dir)+".asm"), nil
+}
+
+type Translator struct {
+	vmFiles    []string
+	program    *Program
+	codeWriter *CodeWriter
+}
+
+func NewTranslator(vmFiles []string, outputFile string, opts Options) (*Translator, error) {
+	cw, err := NewCodeWriter(outputFile, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Translator{vmFiles: vmFiles, codeWriter: cw}
+	return t, nil
+}
+
+// The parsed program, once Run has been called
+func (t *Translator) Program() *Program {
+	return t.program
+}
+
+// Parses every file into the program model, then generates the code for
+// it, writing the output only if every file was valid. Returns every
+// invalid command found, joined together with errors.Join.
+func (t *Translator) Run() error {
+	program, err := ParseProgram(t.vmFiles)
+	t.program = program
+	if err != nil {
+		return err
+	}
+	if err := checkSymbols(program); err != nil {
+		return err
+	}
+	if err := checkExtensions(program, t.codeWriter.opts); err != nil {
+		return err
+	}
+	if err := t.resolveBootstrap(); err != nil {
+		return err
+	}
+	opts := t.codeWriter.opts
+	if opts.VerifyStack {
+		depths, err := VerifyStack(program)
+		if err != nil {
+			return err
+		}
+		if opts.Report != nil {
+			for _, depth := range depths {
+				fmt.Fprintf(opts.Report, "Max stack depth %v: %s\n", depth.MaxDepth, depth.Function)
+			}
+		}
+	}
+	if opts.EliminateDeadFunctions {
+		removed, err := EliminateDeadFunctions(program, opts.entryFunction(), opts.Bootstrap)
+		if err != nil {
+			return err
+		}
+		if opts.Report != nil && len(removed) > 0 {
+			fmt.Fprintf(opts.Report, "Removed %v unreachable functions: %s\n", len(removed), strings.Join(removed, ", "))
+		} else if opts.Report != nil {
+			fmt.Fprintln(opts.Report, "Removed no unreachable functions")
+		}
+	}
+	if opts.OptimizationLevel >= 2 {
+		OptimizeProgram(program)
+	}
+
+	generate(program, t.codeWriter)
+	if t.codeWriter.opts.Report != nil {
+		if err := t.writeReport(t.codeWriter.opts.Report); err != nil {
+			return err
+		}
+	}
+
+	return t.codeWriter.Close()
+}
+
+// Settles whether to emit the bootstrap code, now the program is known,
+// and checks the function it calls exists
+func (t *Translator) resolveBootstrap() error {
+	opts := &t.codeWriter.opts
+	entry := opts.entryFunction()
+	_, defined := BuildCallGraph(t.program).Functions[entry]
+	if opts.AutoBootstrap {
+		opts.Bootstrap = defined
+	} else if opts.Bootstrap && !defined {
+		return fmt.Errorf("bootstrap calls %s, which is not defined", entry)
+	}
+	return nil
+}
+
+func generate(program *Program, cw *CodeWriter) {
+	if cw.opts.Bootstrap {
+		cw.WriteBootstrap()
+	}
+	for _, module := range program.Modules {
+		cw.SetFileName(module.Name)
+		for _, cmd := range module.Commands {
+			cw.WriteCommand(cmd)
+		}
+	}
+	// Without the bootstrap the program runs through every file's code
+	// into the halt loop, which is otherwise only reached by halt commands
+	if !cw.opts.Bootstrap || cw.halts {
+		cw.WriteInfiniteLoop()
+	}
+	cw.WriteSharedRoutines()
+}
+
+func (t *Translator) writeReport(w io.Writer) error {
+	instructions := countInstructions(t.codeWriter.Output())
+	fmt.Fprintf(w, "Instructions: %v (%.2f%% of the ROM)\n", instructions, float64(instructions)*100/32768)
+
+	if t.codeWriter.opts.OptimizeSize {
+		// The same program without the shared routines, for comparison
+		opts := t.codeWriter.opts
+		opts.OptimizeSize = false
+		inline, err := NewCodeWriter(t.codeWriter.outputFilename, opts)
+		if err != nil {
+			return err
+		}
+		generate(t.program, inline)
+		before := countInstructions(inline.Output())
+		fmt.Fprintf(w, "Shared routines: %v instructions before, %v after, saving %v\n", before, instructions, before-instructions)
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package translator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DigUpTheHatchet/nand2tetris/projects/06_Assembler_In_Go/assembler"
+)
+
+// Project 07 Tests
+func TestTranslator_BasicTest(t *testing.T) {
+	translate(t, "BasicTest.vm", false)
+
+	expected := readASMFileContents("BasicTestExpected.asm")
+	actual := readASMFileContents("BasicTest.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_StackTest(t *testing.T) {
+	translate(t, "StackTest.vm", false)
+
+	expected := readASMFileContents("StackTestExpected.asm")
+	actual := readASMFileContents("StackTest.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_SimpleAdd(t *testing.T) {
+	translate(t, "SimpleAdd.vm", false)
+
+	expected := readASMFileContents("SimpleAddExpected.asm")
+	actual := readASMFileContents("SimpleAdd.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_PointerTest(t *testing.T) {
+	translate(t, "PointerTest.vm", false)
+
+	expected := readASMFileContents("PointerTestExpected.asm")
+	actual := readASMFileContents("PointerTest.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_StaticTest(t *testing.T) {
+	translate(t, "StaticTest.vm", false)
+
+	expected := readASMFileContents("StaticTestExpected.asm")
+	actual := readASMFileContents("StaticTest.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+// Project 08 Tests
+func TestTranslator_BasicLoop(t *testing.T) {
+	translate(t, "BasicLoop.vm", false)
+
+	expected := readASMFileContents("BasicLoopExpected.asm")
+	actual := readASMFileContents("BasicLoop.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_FibonacciSeries(t *testing.T) {
+	translate(t, "FibonacciSeries.vm", false)
+
+	expected := readASMFileContents("FibonacciSeriesExpected.asm")
+	actual := readASMFileContents("FibonacciSeries.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_SimpleFunction(t *testing.T) {
+	translate(t, "SimpleFunction.vm", false)
+
+	expected := readASMFileContents("SimpleFunctionExpected.asm")
+	actual := readASMFileContents("SimpleFunction.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_FibonacciElement(t *testing.T) {
+	translate(t, "FibonacciElement", true)
+
+	expected := readASMFileContents("FibonacciElement/FibonacciElementExpected.asm")
+	actual := readASMFileContents("FibonacciElement/FibonacciElement.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslator_StaticsTest(t *testing.T) {
+	translate(t, "StaticsTest", true)
+
+	expected := readASMFileContents("StaticsTest/StaticsTestExpected.asm")
+	actual := readASMFileContents("StaticsTest/StaticsTest.asm")
+
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslate_Errors(t *testing.T) {
+	if err := Translate([]string{}, Options{}); err == nil {
+		t.Error("Expected an error for no inputs")
+	}
+	if err := Translate([]string{"testfiles/Missing.vm"}, Options{}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+	if err := Translate([]string{"testfiles/BasicTest.vm", "testfiles/StackTest.vm"}, Options{}); err == nil {
+		t.Error("Expected an error for multiple inputs without an output file")
+	}
+	if err := Translate([]string{"testfiles/BasicTest.vm"}, Options{OptimizationLevel: MaxOptimizationLevel + 1}); err == nil {
+		t.Error("Expected an error for an unsupported optimization level")
+	}
+}
+
+func TestTranslate_StripComments(t *testing.T) {
+	outputFile := t.TempDir() + "/SimpleAdd.asm"
+	if err := Translate([]string{"testfiles/SimpleAdd.vm"}, Options{OutputFile: outputFile, StripComments: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{}
+	for _, line := range readASMFileContents("SimpleAddExpected.asm") {
+		if !strings.HasPrefix(line, "//") {
+			expected = append(expected, line)
+		}
+	}
+	contents, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlicesEqual(t, expected, strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n"))
+}
+
+func TestOptimizeASM(t *testing.T) {
+	input := []string{
+		"// push constant 8", "@8", "D=A", "@SP", "A=M", "M=D", "@SP", "M=M+1",
+		"// add", "@SP", "AM=M-1", "D=M", "@SP", "AM=M-1", "M=D+M", "@SP", "M=M+1",
+		"(LOOP)", "@SP", "AM=M-1",
+	}
+	expected := []string{
+		"// push constant 8", "@8", "D=A", "@SP", "A=M", "M=D",
+		"// add", "@SP", "A=M", "D=M", "@SP", "AM=M-1", "M=D+M", "@SP", "M=M+1",
+		"(LOOP)", "@SP", "AM=M-1",
+	}
+	assertSlicesEqual(t, expected, optimizeASM(input))
+}
+
+// Helper Functions
+func translate(t *testing.T, input string, bootstrap bool) {
+	if err := Translate([]string{"testfiles/" + input}, Options{Bootstrap: bootstrap}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readASMFileContents(filename string) []string {
+	if !strings.HasSuffix(filename, ".asm") {
+		log.Fatal(errors.New("Filename: (%s) must have .asm extension.."))
+	}
+
+	file, err := os.Open("testfiles/" + filename)
+	defer file.Close()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var asmLines []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		asmLines = append(asmLines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return asmLines
+}
+
+func assertSlicesEqual(t *testing.T, expected, actual []string) {
+	if len(expected) != len(actual) {
+		t.Fatalf("Slice are not equal, lengths differ..\n")
+	}
+	for i, element := range expected {
+		if element != actual[i] {
+			t.Logf("Expected: %v != Actual: %v", element, actual[i])
+			t.Fatalf("Slice equality check failed on index: %v", i)
+		}
+	}
+	t.Logf("Slices with length %v were equal!\n", len(expected))
+}
+
+func TestTranslate_InputPaths(t *testing.T) {
+	vmFile, err := os.ReadFile("testfiles/StaticTest.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "nested", "Prog")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Main.vm"), vmFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := map[string]string{
+		// A single file, given by absolute path
+		filepath.Join(dir, "Main.vm"): filepath.Join(dir, "Main.asm"),
+		// A directory, with and without a trailing separator
+		dir:                              filepath.Join(dir, "Prog.asm"),
+		dir + string(filepath.Separator): filepath.Join(dir, "Prog.asm"),
+	}
+	for input, expectedOutput := range inputs {
+		os.Remove(expectedOutput)
+		if err := Translate([]string{input}, Options{}); err != nil {
+			t.Errorf("Translating %s: %v", input, err)
+			continue
+		}
+		if _, err := os.Stat(expectedOutput); err != nil {
+			t.Errorf("Translating %s: expected output %s: %v", input, expectedOutput, err)
+		}
+	}
+
+	// Statics are named after the file, not its path
+	contents, err := os.ReadFile(filepath.Join(dir, "Prog.asm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "nested") || !strings.Contains(string(contents), "@Main.") {
+		t.Error("Expected statics named after Main.vm, without its directory")
+	}
+}
+
+func TestTranslate_InvalidCommands(t *testing.T) {
+	source := []string{
+		"// Every line but the valid ones is an error",
+		"push local",
+		"push local 2 // valid",
+		"pop constant 5",
+		"push temp 9",
+		"push pointer 2",
+		"push local -1",
+		"push local x",
+		"push heap 0",
+		"add 1",
+		"jump LOOP",
+		"label 1LOOP",
+		"function Main.main",
+		"",
+		"return",
+		"halt now",
+	}
+	vmFile := filepath.Join(t.TempDir(), "Invalid.vm")
+	if err := os.WriteFile(vmFile, []byte(strings.Join(source, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Translate([]string{vmFile}, Options{})
+	if err == nil {
+		t.Fatal("Expected errors for the invalid commands")
+	}
+
+	expected := []string{
+		vmFile + `:2: push local: expected "push segment index"`,
+		vmFile + ":4: pop constant 5: can't pop to the constant segment",
+		vmFile + ":5: push temp 9: temp index 9 is out of range, expected 0-7",
+		vmFile + ":6: push pointer 2: pointer index 2 is out of range, expected 0-1",
+		vmFile + `:7: push local -1: -1 is not a non-negative integer, expected "push segment index"`,
+		vmFile + `:8: push local x: x is not a non-negative integer, expected "push segment index"`,
+		vmFile + ":9: push heap 0: unknown segment heap, expected one of local, argument, this, that, constant, static, pointer or temp",
+		vmFile + `:10: add 1: expected "add"`,
+		vmFile + ":11: jump LOOP: unknown command jump",
+		vmFile + ":12: label 1LOOP: 1LOOP is not a valid symbol, expected letters, digits, _, . and : not starting with a digit",
+		vmFile + `:13: function Main.main: expected "function name nVars"`,
+		vmFile + `:16: halt now: expected "halt"`,
+	}
+	assertSlicesEqual(t, expected, strings.Split(err.Error(), "\n"))
+
+	if _, err := os.Stat(strings.TrimSuffix(vmFile, ".vm") + ".asm"); err == nil {
+		t.Error("Expected no output file for an invalid program")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	module, err := ParseFile("testfiles/SimpleFunction.vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module.Name != "SimpleFunction.vm" || len(module.Commands) != 10 {
+		t.Fatalf("Expected 10 commands from SimpleFunction.vm, got %v from %s", len(module.Commands), module.Name)
+	}
+
+	function := module.Commands[0]
+	if function.Type != C_FUNCTION || function.Function != "SimpleFunction.test" || function.NVars != 2 {
+		t.Errorf("Unexpected function command: %+v", function)
+	}
+	push := module.Commands[5]
+	if push.Type != C_PUSH || push.Segment != "argument" || push.Index != 0 {
+		t.Errorf("Unexpected push command: %+v", push)
+	}
+	if push.Source.String() != "testfiles/SimpleFunction.vm:12" {
+		t.Errorf("Expected push argument 0 at testfiles/SimpleFunction.vm:12, got %v", push.Source)
+	}
+
+	expected := []string{
+		"function SimpleFunction.test 2", "push local 0", "push local 1", "add", "not",
+		"push argument 0", "add", "push argument 1", "sub", "return",
+	}
+	actual := []string{}
+	for _, cmd := range module.Commands {
+		actual = append(actual, cmd.String())
+	}
+	assertSlicesEqual(t, expected, actual)
+}
+
+func TestTranslate_SymbolCollisions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]string{
+		"Main.vm": {
+			"function Main.main 0",
+			"label LOOP",
+			"label LOOP",
+			"goto DONE",
+			"push static 3",
+			"return",
+			"function Main.3 0",
+			"label DONE",
+			"return",
+		},
+		"Other.vm": {
+			"function Main.main 0",
+			"if-goto MISSING",
+			"return",
+			"function SCREEN 0",
+			"return",
+			"function R1 0",
+			"return",
+		},
+	}
+	for name, lines := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Translate([]string{dir}, Options{})
+	if err == nil {
+		t.Fatal("Expected errors for the colliding symbols")
+	}
+
+	main, other := filepath.Join(dir, "Main.vm"), filepath.Join(dir, "Other.vm")
+	expected := []string{
+		main + ":3: label LOOP: label Main.main$LOOP collides with the label at " + main + ":2",
+		main + ":7: function Main.3 0: function Main.3 collides with the static at " + main + ":5",
+		other + ":1: function Main.main 0: function Main.main collides with the function at " + main + ":1",
+		other + ":4: function SCREEN 0: function SCREEN collides with the predefined Hack symbol",
+		other + ":6: function R1 0: function R1 collides with the predefined Hack symbol",
+		main + ":4: goto DONE: no label DONE in Main.main",
+		other + ":2: if-goto MISSING: no label MISSING in Main.main",
+	}
+	assertSlicesEqual(t, expected, strings.Split(err.Error(), "\n"))
+}
+
+func TestTranslate_SizeReport(t *testing.T) {
+	report := &bytes.Buffer{}
+	opts := Options{Bootstrap: true, OptimizeSize: true, OutputFile: filepath.Join(t.TempDir(), "Out.asm"), Report: report}
+	if err := Translate([]string{"testfiles/FibonacciElement"}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after, saving int
+	lines := strings.Split(report.String(), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Unexpected report: %s", report)
+	}
+	if _, err := fmt.Sscanf(lines[1], "Shared routines: %d instructions before, %d after, saving %d", &before, &after, &saving); err != nil {
+		t.Fatalf("Unexpected report: %s (%v)", report, err)
+	}
+	if after >= before || saving != before-after {
+		t.Errorf("Expected the shared routines to save instructions: %s", report)
+	}
+}
+
+func TestOptimizeVM(t *testing.T) {
+	tests := []struct {
+		input    []string
+		expected []string
+	}{
+		{[]string{"push constant 0", "not"}, []string{"push constant -1"}},
+		{[]string{"push constant 7", "push constant 8", "add", "push constant 2", "sub"}, []string{"push constant 13"}},
+		{[]string{"push constant 20000", "neg", "push constant 20000", "lt"}, []string{"push constant -1"}},
+		{[]string{"push constant 32767", "push constant 1", "add"}, []string{"push constant -32768"}},
+		{[]string{"push local 0", "pop local 0"}, []string{}},
+		{[]string{"push argument 1", "pop pointer 1"}, []string{"move argument 1 to pointer 1"}},
+		{[]string{"push local 0", "pop temp 0", "push temp 0"}, []string{"move local 0 to temp 0", "push temp 0"}},
+		{[]string{"push local 0", "push constant 1", "add"}, []string{"push local 0", "add constant 1"}},
+		{[]string{"push local 0", "push constant 32767", "push constant 1", "add", "add"}, []string{"push local 0", "add constant -32768"}},
+		{[]string{"push local 0", "push constant 3", "sub", "push constant 3", "add"}, []string{"push local 0"}},
+		{[]string{"push local 0", "push constant 0", "add", "not", "not", "neg", "neg"}, []string{"push local 0"}},
+		{[]string{"goto END", "label END"}, []string{"label END"}},
+		// A label between the commands could be jumped to, so stops any rewriting
+		{[]string{"push constant 1", "label LOOP", "add"}, []string{"push constant 1", "label LOOP", "add"}},
+		{[]string{"push local 0", "label LOOP", "pop local 0"}, []string{"push local 0", "label LOOP", "pop local 0"}},
+	}
+
+	for _, test := range tests {
+		module := parseVMSource(t, test.input)
+		actual := []string{}
+		for _, cmd := range optimizeVM(module.Commands) {
+			actual = append(actual, cmd.String())
+		}
+		if strings.Join(actual, "; ") != strings.Join(test.expected, "; ") {
+			t.Errorf("Optimizing %v: expected %v, got %v", test.input, test.expected, actual)
+		}
+	}
+}
+
+func parseVMSource(t *testing.T, source []string) *Module {
+	vmFile := filepath.Join(t.TempDir(), "Prog.vm")
+	if err := os.WriteFile(vmFile, []byte(strings.Join(source, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	module, err := ParseFile(vmFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return module
+}
+
+func TestEliminateDeadFunctions(t *testing.T) {
+	module := parseVMSource(t, []string{
+		"function Sys.init 0",
+		"call Main.fib 0",
+		"return",
+		"function Main.fib 0",
+		"call Main.fib 0",
+		"return",
+		"function Main.unused 0",
+		"call Main.onlyFromUnused 0",
+		"return",
+		"function Main.onlyFromUnused 0",
+		"call Main.unused 0",
+		"return",
+	})
+	program := &Program{Modules: []*Module{module}}
+
+	removed, err := EliminateDeadFunctions(program, "Sys.init", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlicesEqual(t, []string{"Main.unused", "Main.onlyFromUnused"}, removed)
+
+	remaining := []string{}
+	for _, cmd := range module.Commands {
+		remaining = append(remaining, cmd.String())
+	}
+	assertSlicesEqual(t, []string{"function Sys.init 0", "call Main.fib 0", "return", "function Main.fib 0", "call Main.fib 0", "return"}, remaining)
+
+	if _, err := EliminateDeadFunctions(program, "Main.main", true); err == nil {
+		t.Error("Expected an error for an undefined entry function")
+	}
+
+	// Without the bootstrap, the calls from top level code are the only roots
+	module = parseVMSource(t, []string{
+		"push constant 1",
+		"call Main.used 1",
+		"label END",
+		"goto END",
+		"function Main.unused 0",
+		"return",
+		"function Main.used 0",
+		"return",
+	})
+	removed, err = EliminateDeadFunctions(&Program{Modules: []*Module{module}}, "Sys.init", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlicesEqual(t, []string{"Main.unused"}, removed)
+
+	// And without top level code the program starts in its first function
+	module = parseVMSource(t, []string{
+		"function Main.test 0",
+		"call Main.used 0",
+		"return",
+		"function Main.used 0",
+		"return",
+		"function Main.unused 0",
+		"return",
+	})
+	removed, err = EliminateDeadFunctions(&Program{Modules: []*Module{module}}, "Sys.init", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSlicesEqual(t, []string{"Main.unused"}, removed)
+}
+
+func TestCallGraph(t *testing.T) {
+	module := parseVMSource(t, []string{
+		"call Sys.init 0",
+		"function Sys.init 0",
+		"call Main.even 0",
+		"call Main.even 0",
+		"call Math.multiply 2",
+		"return",
+		"function Main.even 0",
+		"call Main.odd 0",
+		"return",
+		"function Main.odd 0",
+		"call Main.even 0",
+		"call Main.odd 0",
+		"call Output.printInt 1",
+		"return",
+		"function Main.loop 0",
+		"call Main.loop 0",
+		"call Math.multiply 2",
+		"return",
+	})
+	graph := BuildCallGraph(&Program{Modules: []*Module{module}})
+
+	if graph.Functions["Sys.init"].Calls["Main.even"] != 2 || graph.TopLevelCalls["Sys.init"] != 1 {
+		t.Errorf("Unexpected call counts: %v, %v", graph.Functions["Sys.init"].Calls, graph.TopLevelCalls)
+	}
+
+	cycles := []string{}
+	for _, cycle := range graph.Cycles() {
+		cycles = append(cycles, strings.Join(cycle, " "))
+	}
+	assertSlicesEqual(t, []string{"Main.even Main.odd", "Main.loop"}, cycles)
+
+	undefined := []string{}
+	for _, function := range graph.Undefined() {
+		undefined = append(undefined, function.Name+" <- "+strings.Join(function.CalledBy, " "))
+	}
+	assertSlicesEqual(t, []string{"Math.multiply <- Sys.init Main.loop", "Output.printInt <- Main.odd"}, undefined)
+
+	dot := &bytes.Buffer{}
+	if err := graph.WriteDOT(dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"Sys.init" -> "Main.even" [label="2 calls"];`,
+		`"Main.odd" -> "Main.even" [label="1 call", color=red];`,
+		`"Math.multiply" [style=dashed`,
+		`"(top level)" -> "Sys.init"`,
+	} {
+		if !strings.Contains(dot.String(), expected) {
+			t.Errorf("Expected the DOT output to contain %s:\n%s", expected, dot)
+		}
+	}
+
+	var exported struct {
+		Functions []struct {
+			Name  string         `json:"name"`
+			Calls map[string]int `json:"calls"`
+		} `json:"functions"`
+		Cycles    [][]string          `json:"cycles"`
+		Undefined []UndefinedFunction `json:"undefined"`
+	}
+	output := &bytes.Buffer{}
+	if err := graph.WriteJSON(output); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(output.Bytes(), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported.Functions) != 4 || exported.Functions[0].Name != "Sys.init" || len(exported.Cycles) != 2 || len(exported.Undefined) != 2 {
+		t.Errorf("Unexpected JSON call graph: %s", output)
+	}
+}
+
+func TestVerifyStack(t *testing.T) {
+	module := parseVMSource(t, []string{
+		"function Main.countdown 0",
+		"push argument 0",
+		"label LOOP",
+		"push constant 1",
+		"sub",
+		"push constant 0",
+		"pop temp 0",
+		"push local 0",
+		"if-goto LOOP",
+		"return",
+		"function Main.unbalanced 0",
+		"push argument 0",
+		"if-goto SKIP",
+		"push constant 2",
+		"label SKIP",
+		"push constant 0",
+		"return",
+		"function Main.underflow 1",
+		"push local 0",
+		"add",
+		"return",
+		"function Main.empty 0",
+		"return",
+	})
+
+	depths, err := VerifyStack(&Program{Modules: []*Module{module}})
+	if err == nil {
+		t.Fatal("Expected stack errors")
+	}
+	expected := []string{
+		module.Path + ":15: label SKIP: in Main.unbalanced, reached with stack depth 0 and 1",
+		module.Path + ":20: add: in Main.underflow, stack underflow, needs 2 values but only 1 pushed",
+		module.Path + ":23: return: in Main.empty, return with an empty stack",
+	}
+	assertSlicesEqual(t, expected, strings.Split(err.Error(), "\n"))
+
+	if len(depths) != 4 || depths[0].Function != "Main.countdown" || depths[0].MaxDepth != 2 {
+		t.Errorf("Unexpected max depths: %+v", depths)
+	}
+}
+
+func TestTranslate_SourceMap(t *testing.T) {
+	for _, opts := range []Options{{}, {StripComments: true, OptimizationLevel: 1}, {CacheTOS: true}} {
+		opts.SourceMap = true
+		opts.OutputFile = filepath.Join(t.TempDir(), "Out.asm")
+		if err := Translate([]string{"testfiles/SimpleAdd.vm"}, opts); err != nil {
+			t.Fatal(err)
+		}
+		asmMap, err := assembler.LoadSourceMap(opts.OutputFile + ".map")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Chained through the assembler's map, every instruction of the VM
+		// code points back to its .vm line, and the halt loop to Out.asm
+		file, err := os.Open(opts.OutputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines, err := assembler.ParseSourceLines(opts.OutputFile, file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := assembler.NewAssembler()
+		a.DebugOutput = io.Discard
+		if _, err := a.Assemble(lines); err != nil {
+			t.Fatal(err)
+		}
+		vmLines := []int{}
+		for _, entry := range a.SourceMap("Out.hack").Chain(asmMap).Mappings {
+			if entry.File == "testfiles/SimpleAdd.vm" {
+				if len(vmLines) == 0 || vmLines[len(vmLines)-1] != entry.Line {
+					vmLines = append(vmLines, entry.Line)
+				}
+			} else if entry.File != opts.OutputFile {
+				t.Errorf("%+v: unexpected source %+v", opts, entry)
+			}
+		}
+		if fmt.Sprint(vmLines) != "[7 8 9]" {
+			t.Errorf("%+v: expected the code for lines 7, 8 and 9 in order, got %v", opts, vmLines)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package translator
+
+// Peephole optimization of the VM commands, before any code is generated.
+// Patterns only ever match consecutive commands, so never span a label
+// that could be jumped to.
+
+// Optimizes every module's commands in place
+func OptimizeProgram(program *Program) {
+	for _, module := range program.Modules {
+		module.Commands = optimizeVM(module.Commands)
+	}
+}
+
+// Applies the VM peephole patterns until none of them match
+func optimizeVM(commands []Command) []Command {
+	output := []Command{}
+	for _, cmd := range commands {
+		output = append(output, cmd)
+		// Each rewrite can enable another on the commands before it, e.g.
+		// push constant 1; push constant 2; add; add -> push constant 3; add
+		// -> add constant 3
+		for {
+			rewritten, ok := rewriteTail(output)
+			if !ok {
+				break
+			}
+			output = rewritten
+		}
+	}
+	return output
+}
+
+// Tries each pattern on the last commands, returning the rewritten list
+func rewriteTail(commands []Command) ([]Command, bool) {
+	n := len(commands)
+	if n < 2 {
+		return commands, false
+	}
+	previous, last := commands[n-2], commands[n-1]
+	head := commands[:n-2]
+
+	// Constant folding: push constant a; unary op
+	if a, ok := pushedConstant(previous); ok && last.Type == C_ARITHMETIC {
+		if result, ok := foldUnary(last.Op, a); ok {
+			return append(head, pushConstant(result, previous)), true
+		}
+	}
+
+	// Constant folding: push constant a; push constant b; binary op
+	if n >= 3 && last.Type == C_ARITHMETIC {
+		a, aConstant := pushedConstant(commands[n-3])
+		b, bConstant := pushedConstant(previous)
+		if aConstant && bConstant {
+			if result, ok := foldBinary(last.Op, a, b); ok {
+				return append(commands[:n-3], pushConstant(result, commands[n-3])), true
+			}
+		}
+	}
+
+	// push constant k; add/sub -> add constant +-k, or nothing if k is 0
+	if k, ok := pushedConstant(previous); ok && last.Type == C_ARITHMETIC && (last.Op == "add" || last.Op == "sub") {
+		if last.Op == "sub" {
+			k = int(-int16(k))
+		}
+		if k == 0 {
+			return head, true
+		}
+		add := Command{Type: C_ADD_CONSTANT, Value: k, Source: previous.Source}
+		add.Text = add.String()
+		return append(head, add), true
+	}
+
+	// add constant a; add constant b -> add constant a+b
+	if previous.Type == C_ADD_CONSTANT && last.Type == C_ADD_CONSTANT {
+		add := previous
+		add.Value = int(int16(previous.Value + last.Value))
+		add.Text = add.String()
+		if add.Value == 0 {
+			return head, true
+		}
+		return append(head, add), true
+	}
+
+	// push x; pop x does nothing, push x; pop y is a direct move
+	if previous.Type == C_PUSH && last.Type == C_POP {
+		if previous.Segment == last.Segment && previous.Index == last.Index {
+			return head, true
+		}
+		move := Command{Type: C_MOVE, Segment: previous.Segment, Index: previous.Index,
+			DestSegment: last.Segment, DestIndex: last.Index, Source: previous.Source}
+		move.Text = move.String()
+		return append(head, move), true
+	}
+
+	// not; not and neg; neg cancel out
+	if previous.Type == C_ARITHMETIC && last.Type == C_ARITHMETIC && previous.Op == last.Op &&
+		(last.Op == "not" || last.Op == "neg") {
+		return head, true
+	}
+
+	// goto L; label L
+	if previous.Type == C_GOTO && last.Type == C_LABEL && previous.Label == last.Label {
+		return append(head, last), true
+	}
+
+	return commands, false
+}
+
+func pushedConstant(cmd Command) (int, bool) {
+	return cmd.Index, cmd.Type == C_PUSH && cmd.Segment == "constant"
+}
+
+// A push of any 16-bit value, which can be negative after folding
+func pushConstant(value int16, source Command) Command {
+	cmd := Command{Type: C_PUSH, Segment: "constant", Index: int(value), Source: source.Source}
+	cmd.Text = cmd.String()
+	return cmd
+}
+
+func foldUnary(op string, a int) (int16, bool) {
+	switch op {
+	case "neg":
+		return -int16(a), true
+	case "not":
+		return ^int16(a), true
+	}
+	return 0, false
+}
+
+func foldBinary(op string, a int, b int) (int16, bool) {
+	x, y := int16(a), int16(b)
+	switch op {
+	case "add":
+		return x + y, true
+	case "sub":
+		return x - y, true
+	case "and":
+		return x & y, true
+	case "or":
+		return x | y, true
+	case "eq":
+		return vmBool(x == y), true
+	case "gt":
+		return vmBool(x > y), true
+	case "lt":
+		return vmBool(x < y), true
+	}
+	return foldExtension(op, x, y)
+}
+
+// VM true is -1, all bits set
+func vmBool(b bool) int16 {
+	if b {
+		return -1
+	}
+	return 0
+}